@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+// fakeBackend is an in-process moviedb-backend serving the JSON fixtures in _fixtures/backend.
+// Its routes are HMAC secured, exactly like the real backend.
+type fakeBackend struct {
+	handler    http.Handler
+	data       sync.Mutex // held by every handler, they read and write the movies
+	movies     []*moviedb.Movie
+	statistics *moviedb.Statistics
+	mutex      sync.Mutex
+	status     int
+	failing    map[string]int
+	failTimes  int
+	hang       bool
+	hanging    chan struct{}
+	closed     chan struct{}
+}
+
+func newFakeBackend() *fakeBackend {
+	fb := &fakeBackend{
+		failing: make(map[string]int),
+		hanging: make(chan struct{}, 100),
+		closed:  make(chan struct{}),
+	}
+	if err := readFixture("movies.json", &fb.movies); err != nil {
+		panic(err)
+	}
+	if err := readFixture("statistics.json", &fb.statistics); err != nil {
+		panic(err)
+	}
+
+	backend := web.NewBackend()
+	backend.NewSecuredRoute("/movies", fb.handle(fb.movieListing))
+	backend.NewSecuredRoute("/movie", fb.handle(fb.addMovie)).Methods("POST")
+	backend.NewSecuredRoute("/movie/{id}", fb.handle(fb.movie)).Methods("GET")
+	backend.NewSecuredRoute("/movie/{id}", fb.handle(fb.saveMovie)).Methods("PUT")
+	backend.NewSecuredRoute("/movie/{id}", fb.handle(fb.deleteMovie)).Methods("DELETE")
+	backend.NewSecuredRoute("/person/{id}", fb.handle(fb.person))
+	backend.NewSecuredRoute("/actors", fb.handle(fb.actors))
+	backend.NewSecuredRoute("/directors", fb.handle(fb.directors))
+	backend.NewSecuredRoute("/genres", fb.handle(fb.genres))
+	backend.NewSecuredRoute("/languages", fb.handle(fb.languages))
+	backend.NewSecuredRoute("/statistics", fb.handle(fb.stats))
+
+	// the HMAC check consumes the request body, the handlers get a copy of it
+	fb.handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		backend.Router.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), bodyKey{}, body)))
+	})
+	return fb
+}
+
+// backendServer serves the fake backend of the running test, or a shared one
+// if the test didn't ask for its own, see useBackend.
+type backendServer struct {
+	*httptest.Server
+	shared  *fakeBackend
+	mutex   sync.Mutex
+	current *fakeBackend
+}
+
+func newBackendServer() *backendServer {
+	s := &backendServer{shared: newFakeBackend()}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		fb := s.current
+		s.mutex.Unlock()
+		if fb == nil {
+			fb = s.shared
+		}
+		fb.handler.ServeHTTP(w, req)
+	}))
+	return s
+}
+
+// useBackend gives the running test a fake backend of its own, until the returned func is called.
+// The test may fail or change it, no other test is going to notice.
+func useBackend() (*fakeBackend, func()) {
+	fb := newFakeBackend()
+	backends.mutex.Lock()
+	backends.current = fb
+	backends.mutex.Unlock()
+
+	return fb, func() {
+		backends.mutex.Lock()
+		backends.current = nil
+		backends.mutex.Unlock()
+		close(fb.closed)
+	}
+}
+
+type bodyKey struct{}
+
+func requestBody(req *http.Request) []byte {
+	body, _ := req.Context().Value(bodyKey{}).([]byte)
+	return body
+}
+
+func readFixture(name string, v interface{}) error {
+	data, err := ioutil.ReadFile("_fixtures/backend/" + name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Fail lets every following request fail with status, until Reset is called.
+func (fb *fakeBackend) Fail(status int) {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+	fb.status = status
+}
+
+// FailURL lets every following request whose URL contains part fail with status, until Reset is called.
+func (fb *fakeBackend) FailURL(part string, status int) {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+	fb.failing[part] = status
+}
+
+// FailTimes lets the next n requests fail with status.
+func (fb *fakeBackend) FailTimes(n, status int) {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+	fb.failTimes = n
+	fb.status = status
+}
+
+// Hang lets every following request wait until it is given up, until Reset is called.
+// Every hanging request is announced on the returned channel.
+func (fb *fakeBackend) Hang() <-chan struct{} {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+	fb.hang = true
+	return fb.hanging
+}
+
+func (fb *fakeBackend) Reset() {
+	fb.mutex.Lock()
+	defer fb.mutex.Unlock()
+	fb.status = 0
+	fb.hang = false
+	fb.failTimes = 0
+	fb.failing = make(map[string]int)
+}
+
+func (fb *fakeBackend) handle(fn web.Handler) web.Handler {
+	return func(w http.ResponseWriter, req *http.Request) *web.Page {
+		fb.mutex.Lock()
+		status, hang := fb.status, fb.hang
+		if fb.failTimes > 0 {
+			if fb.failTimes--; fb.failTimes == 0 {
+				fb.status = 0
+			}
+		}
+		for part, s := range fb.failing {
+			if strings.Contains(req.URL.RequestURI(), part) {
+				status = s
+			}
+		}
+		fb.mutex.Unlock()
+
+		if hang {
+			select {
+			case fb.hanging <- struct{}{}:
+			default:
+			}
+			select {
+			case <-req.Context().Done():
+			case <-fb.closed:
+			}
+			status = http.StatusServiceUnavailable
+		}
+		if status != 0 {
+			return &web.Page{
+				StatusCode: status,
+				Error:      fmt.Errorf("fake backend failure"),
+			}
+		}
+
+		fb.data.Lock()
+		defer fb.data.Unlock()
+		return fn(w, req)
+	}
+}
+
+func (fb *fakeBackend) movieListing(w http.ResponseWriter, req *http.Request) *web.Page {
+	options := moviedb.ParseMovieListingOptions(req)
+
+	listings := make([]*moviedb.MovieListing, 0)
+	var movies []*moviedb.Movie
+	for _, movie := range fb.movies {
+		if matchesMovie(movie, options.Query) {
+			movies = append(movies, movie)
+		}
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		return lessMovie(movies[i], movies[j], options.Sort)
+	})
+	for _, movie := range movies {
+		listings = append(listings, &moviedb.MovieListing{
+			Id:     movie.Id,
+			Title:  movie.Title,
+			Year:   movie.Year,
+			Score:  movie.Score,
+			Rating: movie.Rating,
+		})
+	}
+	return &web.Page{Content: listings}
+}
+
+func (fb *fakeBackend) movie(w http.ResponseWriter, req *http.Request) *web.Page {
+	id := mux.Vars(req)["id"]
+	for _, movie := range fb.movies {
+		if strconv.Itoa(movie.Id) == id {
+			return &web.Page{Content: movie}
+		}
+	}
+	return &web.Page{StatusCode: http.StatusNotFound, Error: fmt.Errorf("sql: no rows in result set")}
+}
+
+// addMovie inserts a movie like moviedb.MovieDB.AddMovie, with the next free Id.
+func (fb *fakeBackend) addMovie(w http.ResponseWriter, req *http.Request) *web.Page {
+	var movie moviedb.Movie
+	if err := json.Unmarshal(requestBody(req), &movie); err != nil {
+		return &web.Page{StatusCode: http.StatusBadRequest, Error: err}
+	}
+
+	for _, m := range fb.movies {
+		if m.Id > movie.Id {
+			movie.Id = m.Id
+		}
+	}
+	movie.Id++
+	fb.resolve(&movie)
+	fb.movies = append(fb.movies, &movie)
+	return &web.Page{StatusCode: http.StatusCreated, Content: &movie}
+}
+
+func (fb *fakeBackend) saveMovie(w http.ResponseWriter, req *http.Request) *web.Page {
+	var movie moviedb.Movie
+	if err := json.Unmarshal(requestBody(req), &movie); err != nil {
+		return &web.Page{StatusCode: http.StatusBadRequest, Error: err}
+	}
+
+	for i, m := range fb.movies {
+		if strconv.Itoa(m.Id) == mux.Vars(req)["id"] {
+			movie.Id = m.Id
+			fb.resolve(&movie)
+			fb.movies[i] = &movie
+			return &web.Page{Content: &movie}
+		}
+	}
+	return &web.Page{StatusCode: http.StatusNotFound, Error: fmt.Errorf("sql: no rows in result set")}
+}
+
+func (fb *fakeBackend) deleteMovie(w http.ResponseWriter, req *http.Request) *web.Page {
+	for i, m := range fb.movies {
+		if strconv.Itoa(m.Id) == mux.Vars(req)["id"] {
+			fb.movies = append(fb.movies[:i:i], fb.movies[i+1:]...)
+			return &web.Page{StatusCode: http.StatusNoContent}
+		}
+	}
+	return &web.Page{StatusCode: http.StatusNotFound, Error: fmt.Errorf("sql: no rows in result set")}
+}
+
+// resolve assigns the Ids of known languages, genres and people by their name,
+// like moviedb.MovieDB.SaveMovie, and new Ids to all others.
+func (fb *fakeBackend) resolve(movie *moviedb.Movie) {
+	languages, genres, people := make(map[string]int), make(map[string]int), make(map[string]int)
+	var maxLanguage, maxGenre, maxPerson int
+	for _, m := range fb.movies {
+		for _, l := range m.Languages {
+			languages[l.Name] = l.Id
+			if l.Id > maxLanguage {
+				maxLanguage = l.Id
+			}
+		}
+		for _, g := range m.Genres {
+			genres[g.Name] = g.Id
+			if g.Id > maxGenre {
+				maxGenre = g.Id
+			}
+		}
+		for _, p := range append(m.Actors, m.Directors...) {
+			people[p.Name] = p.Id
+			if p.Id > maxPerson {
+				maxPerson = p.Id
+			}
+		}
+	}
+
+	id := func(ids map[string]int, max *int, name string) int {
+		if _, ok := ids[name]; !ok {
+			*max++
+			ids[name] = *max
+		}
+		return ids[name]
+	}
+	for _, l := range movie.Languages {
+		l.Id = id(languages, &maxLanguage, l.Name)
+	}
+	for _, g := range movie.Genres {
+		g.Id = id(genres, &maxGenre, g.Name)
+	}
+	for _, p := range append(movie.Actors, movie.Directors...) {
+		p.Id = id(people, &maxPerson, p.Name)
+	}
+}
+
+func (fb *fakeBackend) person(w http.ResponseWriter, req *http.Request) *web.Page {
+	id := mux.Vars(req)["id"]
+	for _, person := range append(fb.people(false), fb.people(true)...) {
+		if strconv.Itoa(person.Id) == id {
+			return &web.Page{Content: person}
+		}
+	}
+	return &web.Page{StatusCode: http.StatusNotFound, Error: fmt.Errorf("sql: no rows in result set")}
+}
+
+func (fb *fakeBackend) actors(w http.ResponseWriter, req *http.Request) *web.Page {
+	return &web.Page{Content: fb.people(false)}
+}
+
+func (fb *fakeBackend) directors(w http.ResponseWriter, req *http.Request) *web.Page {
+	return &web.Page{Content: fb.people(true)}
+}
+
+func (fb *fakeBackend) people(directors bool) []*moviedb.Person {
+	seen := make(map[int]bool)
+	people := make([]*moviedb.Person, 0)
+	for _, movie := range fb.movies {
+		list := movie.Actors
+		if directors {
+			list = movie.Directors
+		}
+		for _, person := range list {
+			if !seen[person.Id] {
+				seen[person.Id] = true
+				people = append(people, person)
+			}
+		}
+	}
+	sort.SliceStable(people, func(i, j int) bool {
+		return collate(people[i].Name) < collate(people[j].Name)
+	})
+	return people
+}
+
+func (fb *fakeBackend) genres(w http.ResponseWriter, req *http.Request) *web.Page {
+	seen := make(map[int]bool)
+	genres := make([]*moviedb.Genre, 0)
+	for _, movie := range fb.movies {
+		for _, genre := range movie.Genres {
+			if !seen[genre.Id] {
+				seen[genre.Id] = true
+				genres = append(genres, genre)
+			}
+		}
+	}
+	sort.SliceStable(genres, func(i, j int) bool {
+		return genres[i].Name < genres[j].Name
+	})
+	return &web.Page{Content: genres}
+}
+
+func (fb *fakeBackend) languages(w http.ResponseWriter, req *http.Request) *web.Page {
+	seen := make(map[int]bool)
+	languages := make([]*moviedb.Language, 0)
+	for _, movie := range fb.movies {
+		for _, language := range movie.Languages {
+			if !seen[language.Id] {
+				seen[language.Id] = true
+				languages = append(languages, language)
+			}
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].Name < languages[j].Name
+	})
+	return &web.Page{Content: languages}
+}
+
+func (fb *fakeBackend) stats(w http.ResponseWriter, req *http.Request) *web.Page {
+	return &web.Page{Content: fb.statistics}
+}
+
+// collate mimics the database collation, which ignores whitespace and case.
+func collate(s string) string {
+	return strings.ToLower(strings.Replace(s, " ", "", -1))
+}
+
+func matchesMovie(movie *moviedb.Movie, queries []moviedb.Query) bool {
+	for _, query := range queries {
+		value := query.Value()
+		switch query.Query() {
+		case "title":
+			if movie.Title != value {
+				return false
+			}
+		case "year":
+			if strconv.Itoa(movie.Year) != value {
+				return false
+			}
+		case "score":
+			if strconv.Itoa(movie.Score) != value {
+				return false
+			}
+		case "rating":
+			if strconv.Itoa(movie.Rating) != value {
+				return false
+			}
+		case "length":
+			if strconv.Itoa(movie.Length) != value {
+				return false
+			}
+		case "disks":
+			if strconv.Itoa(movie.Disks) != value {
+				return false
+			}
+		case "format":
+			if movie.Format != value {
+				return false
+			}
+		case "disk_region":
+			if movie.Region != value {
+				return false
+			}
+		case "disk_type":
+			if movie.Type != value {
+				return false
+			}
+		case "char":
+			first := strings.ToLower(movie.Title[:1])
+			if value == "num" {
+				if first < "0" || first > "9" {
+					return false
+				}
+			} else if first != value {
+				return false
+			}
+		case "search":
+			value = strings.ToLower(value)
+			if !strings.Contains(strings.ToLower(movie.Title), value) &&
+				!strings.Contains(strings.ToLower(movie.Alttitle.String), value) {
+				return false
+			}
+		case "language":
+			found := false
+			for _, language := range movie.Languages {
+				found = found || strconv.Itoa(language.Id) == value
+			}
+			if !found {
+				return false
+			}
+		case "genre":
+			found := false
+			for _, genre := range movie.Genres {
+				found = found || strconv.Itoa(genre.Id) == value
+			}
+			if !found {
+				return false
+			}
+		case "actor":
+			found := false
+			for _, person := range movie.Actors {
+				found = found || strconv.Itoa(person.Id) == value
+			}
+			if !found {
+				return false
+			}
+		case "director":
+			found := false
+			for _, person := range movie.Directors {
+				found = found || strconv.Itoa(person.Id) == value
+			}
+			if !found {
+				return false
+			}
+		default:
+			if strconv.Itoa(movie.Id) != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func lessMovie(a, b *moviedb.Movie, sorts []moviedb.Sort) bool {
+	for _, s := range sorts {
+		var cmp int
+		switch s.Field() {
+		case "title":
+			cmp = strings.Compare(a.Title, b.Title)
+		case "year":
+			cmp = a.Year - b.Year
+		case "score":
+			cmp = a.Score - b.Score
+		case "rating":
+			cmp = a.Rating - b.Rating
+		case "length":
+			cmp = a.Length - b.Length
+		case "disks":
+			cmp = a.Disks - b.Disks
+		case "format":
+			cmp = strings.Compare(a.Format, b.Format)
+		case "disk_region":
+			cmp = strings.Compare(a.Region, b.Region)
+		case "disk_type":
+			cmp = strings.Compare(a.Type, b.Type)
+		default:
+			cmp = a.Id - b.Id
+		}
+		if cmp != 0 {
+			if s.Order() == "desc" {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+	}
+	return false
+}
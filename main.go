@@ -1,29 +1,141 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"encoding/xml"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/gorilla/mux"
 	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/admin"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/auth"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/breaker"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/cache"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/canonical"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/covers"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/export"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/facets"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/fallback"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/fanout"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/feed"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/health"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/listview"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/meta"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/metrics"
 	"github.com/jamesclonk-io/moviedb-frontend/modules/navbar"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/negotiation"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/pagination"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/pictures"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/requestid"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/sitemap"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/slug"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/suggest"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
 	"github.com/jamesclonk-io/stdlib/env"
 	"github.com/jamesclonk-io/stdlib/logger"
 	"github.com/jamesclonk-io/stdlib/web"
 	"github.com/jamesclonk-io/stdlib/web/negroni"
+	"github.com/phyber/negroni-gzip/gzip"
 )
 
 var (
-	log           *logrus.Logger
-	backendClient *web.BackendClient
-	backendUrl    string
+	log              *logrus.Logger
+	dataSource       datasource.Source // cached, and stale while the backend is down
+	uncachedSource   datasource.Source // for the admin section and readiness
+	backendClient    *web.BackendClient
+	backendCache     *cache.Cache
+	backendStale     *fallback.Fallback
+	backendBreaker   *breaker.Breaker
+	backendTransport *breaker.Transport
+	searchIndex      *suggest.Index
+	siteMap          *sitemap.Sitemap
+	moviePictures    *pictures.Pictures
+	navigation       *navbar.Navigation
+	authentication   *auth.Auth
+	backendUrl       string
+	frontendUrl      string
+
+	backendPagination bool
+	moviesPerPage     int
+	peoplePerPage     int
+	feedSize          int
+	detailsLimit      int
+	suggestRefresh    time.Duration
+	navigationRefresh time.Duration
+	pageTimeout       time.Duration
+	backendTimeout    time.Duration
+	backendRetries    int
+	retryBackoff      time.Duration
+	readyTimeout      time.Duration
 )
 
+// detailsConcurrency is how many movies movieDetails loads at once, like pictures.Pictures.
+const detailsConcurrency = 8
+
 func init() {
 	log = logger.GetLogger()
 	backendUrl = env.Get("JCIO_MOVIEDB_BACKEND", "http://moviedb-backend.jamesclonk.io")
+
+	// public URL of the frontend, used for absolute links in feeds,
+	// defaults to the host of the incoming request
+	frontendUrl = env.Get("JCIO_MOVIEDB_FRONTEND_URL", "")
+
+	// the backend ignores page and per_page unless told otherwise,
+	// the frontend then paginates the full listings itself
+	backendPagination = env.Get("JCIO_MOVIEDB_BACKEND_PAGINATION", "false") == "true"
+	moviesPerPage = getEnvInt("JCIO_MOVIEDB_MOVIES_PER_PAGE", 100)
+	peoplePerPage = getEnvInt("JCIO_MOVIEDB_PEOPLE_PER_PAGE", 240)
+	feedSize = getEnvInt("JCIO_MOVIEDB_FEED_SIZE", 25)
+	// browsing and exporting need the full data of every movie, which is loaded one by one
+	detailsLimit = getEnvInt("JCIO_MOVIEDB_DETAILS_LIMIT", 500)
+	suggestRefresh = getEnvDuration("JCIO_MOVIEDB_SUGGEST_REFRESH", 15*time.Minute)
+	readyTimeout = getEnvDuration("JCIO_MOVIEDB_READY_TIMEOUT", 5*time.Second)
+	// every backend request gets a timeout per attempt, failed GETs are retried,
+	// the circuit breaker opens after too many consecutive failures (0 disables it)
+	backendTimeout = getEnvDuration("JCIO_MOVIEDB_BACKEND_TIMEOUT", 5*time.Second)
+	backendRetries = getEnvInt("JCIO_MOVIEDB_BACKEND_RETRIES", 2)
+	retryBackoff = getEnvDuration("JCIO_MOVIEDB_BACKEND_RETRY_BACKOFF", 100*time.Millisecond)
+	backendBreaker = breaker.New(
+		getEnvInt("JCIO_MOVIEDB_BREAKER_THRESHOLD", 5),
+		getEnvDuration("JCIO_MOVIEDB_BREAKER_COOLDOWN", 30*time.Second),
+	)
+	pageTimeout = getEnvDuration("JCIO_MOVIEDB_PAGE_TIMEOUT", 10*time.Second)
+	navigationRefresh = getEnvDuration("JCIO_MOVIEDB_NAVIGATION_REFRESH", 10*time.Minute)
+}
+
+func getEnvInt(key string, nvl int) int {
+	value, err := strconv.Atoi(env.Get(key, strconv.Itoa(nvl)))
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"key":   key,
+		}).Error("Invalid number, using default")
+		return nvl
+	}
+	return value
+}
+
+func getEnvDuration(key string, nvl time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.Get(key, nvl.String()))
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"key":   key,
+		}).Error("Invalid duration, using default")
+		return nvl
+	}
+	return value
 }
 
 type NavigationElement struct {
@@ -33,6 +145,126 @@ type NavigationElement struct {
 	Dropdown []NavigationElement `json:"dropdown,omitempty"`
 }
 
+// PageData is passed as web.Page.Data to the layout template.
+type PageData struct {
+	Stale      bool
+	StaleSince time.Time
+	Pagination *pagination.Pagination
+	Query      string // the filter and sorting of a movie listing, for its exports and feeds
+	Feeds      []FeedLink
+	RequestID  string
+	Canonical  string // absolute URL of the page, without parameters that only change its presentation
+	Meta       *meta.Meta
+	View       *listview.View
+	Pictures   map[int]string
+}
+
+// track returns a copy of ctx marking the page as stale whenever a datasource.Source reads stale data with it.
+func (d *PageData) track(ctx context.Context) context.Context {
+	return datasource.WithStale(ctx, d.markStale)
+}
+
+// markStale marks the page as served from stale responses, the oldest of them updated at since.
+func (d *PageData) markStale(since time.Time) {
+	if !d.Stale || since.Before(d.StaleSince) {
+		d.StaleSince = since
+	}
+	d.Stale = true
+}
+
+// IsStale reports whether the page was served from stale responses, and since when, see negotiation.Stale.
+func (d *PageData) IsStale() (bool, time.Time) {
+	return d.Stale, d.StaleSince
+}
+
+// merge adds the stale state of other to d.
+func (d *PageData) merge(other *PageData) {
+	if other.Stale {
+		d.markStale(other.StaleSince)
+	}
+}
+
+// MovieCard is a movie listing along with the path of its page and its cover picture,
+// shown in the table and grid view.
+type MovieCard struct {
+	Id      int
+	Title   string
+	Year    int
+	Score   int
+	Rating  int
+	Path    string
+	Picture string
+}
+
+// Cards returns listings as cards, with the pictures looked up by setView.
+func (d *PageData) Cards(listings []moviedb.MovieListing) []MovieCard {
+	cards := make([]MovieCard, 0, len(listings))
+	for _, listing := range listings {
+		cards = append(cards, MovieCard{
+			Id:      listing.Id,
+			Title:   listing.Title,
+			Year:    listing.Year,
+			Score:   listing.Score,
+			Rating:  listing.Rating,
+			Path:    slug.Movie(listing.Id, listing.Title),
+			Picture: d.Pictures[listing.Id],
+		})
+	}
+	return cards
+}
+
+// PersonPath returns the path of the page of a person, for the templates.
+func (d *PageData) PersonPath(id int, name string) string {
+	return slug.Person(id, name)
+}
+
+// setView sets the listing view of req. The grid view needs the pictures of listings,
+// which are only looked up then.
+func (d *PageData) setView(w http.ResponseWriter, req *http.Request, listings ...[]moviedb.MovieListing) {
+	d.View = listview.New(w, req)
+	if !d.View.IsGrid() {
+		return
+	}
+	var ids []int
+	for _, l := range listings {
+		for _, listing := range l {
+			ids = append(ids, listing.Id)
+		}
+	}
+	d.Pictures = moviePictures.Get(req.Context(), ids)
+}
+
+// FeedLink is an Atom feed announced by a page.
+type FeedLink struct {
+	Title string
+	URL   string
+}
+
+// PersonDetails is the content of the person page.
+type PersonDetails struct {
+	Person     moviedb.Person         `json:"person"`
+	ActorIn    []moviedb.MovieListing `json:"actor_in"`
+	DirectorOf []moviedb.MovieListing `json:"director_of"`
+	Missing    []string               `json:"missing,omitempty"` // sections that could not be loaded
+}
+
+func (p PersonDetails) XML() interface{} {
+	return &struct {
+		XMLName    xml.Name                   `xml:"person"`
+		Id         int                        `xml:"id,attr"`
+		Name       string                     `xml:"name"`
+		ActorIn    []negotiation.MovieListing `xml:"actor_in>movie"`
+		DirectorOf []negotiation.MovieListing `xml:"director_of>movie"`
+		Missing    []string                   `xml:"missing>section,omitempty"`
+	}{
+		Id:         p.Person.Id,
+		Name:       p.Person.Name,
+		ActorIn:    negotiation.MovieListings(p.ActorIn),
+		DirectorOf: negotiation.MovieListings(p.DirectorOf),
+		Missing:    p.Missing,
+	}
+}
+
 func main() {
 	// setup http handler
 	n := setup()
@@ -43,171 +275,809 @@ func main() {
 }
 
 func setup() *negroni.Negroni {
-	backendClient = web.NewBackendClient()
+	uncachedSource, dataSource = newDataSources()
+	metrics.NewCounterFunc("moviedb_frontend_cache_hits_total", "Number of backend responses served from the cache.",
+		func() float64 {
+			if backendCache == nil {
+				return 0
+			}
+			hits, _ := backendCache.Stats()
+			return float64(hits)
+		})
+	metrics.NewCounterFunc("moviedb_frontend_cache_misses_total", "Number of backend responses not found in the cache.",
+		func() float64 {
+			if backendCache == nil {
+				return 0
+			}
+			_, misses := backendCache.Stats()
+			return float64(misses)
+		})
+
+	metrics.NewGaugeFunc("moviedb_frontend_backend_circuit_open", "Whether the circuit breaker to moviedb-backend is open.",
+		func() float64 {
+			if state, _ := backendBreaker.State(); state == breaker.Open {
+				return 1
+			}
+			return 0
+		})
+
+	// the search index is built in the background, suggestions are empty until then
+	searchIndex = suggest.New(dataSource)
+	go searchIndex.Run(suggestRefresh)
+
+	// the sitemap is rebuilt whenever the backend statistics report an update
+	siteMap = sitemap.New(dataSource, getEnvInt("JCIO_MOVIEDB_SITEMAP_SIZE", sitemap.MaxURLs))
+
+	// listings don't include the cover pictures, the grid view looks them up per movie
+	moviePictures = pictures.New(dataSource, getEnvDuration("JCIO_MOVIEDB_PICTURES_TTL", time.Hour))
+
+	// the genre navigation is refreshed in the background, every page gets the current one
+	navigation = navbar.NewNavigation(uncachedSource)
+	go navigation.Run(navigationRefresh)
+	page := func(fn web.Handler) web.Handler {
+		return auth.Navigation(navigation.Handler(fn))
+	}
 
 	frontend := web.NewFrontend("jamesclonk.io - Movie Database")
+	frontend.SetNavigation(navigation.Get())
+	frontend.Router.NotFoundHandler = metrics.NewHandler(frontend, page(notFound(frontend.Title)))
+	render := func(fn web.Handler) http.HandlerFunc {
+		return metrics.NewHandler(frontend, page(fn))
+	}
+
+	// users log in with a session cookie, reading needs no login unless the site is private,
+	// editors may add and change movies, only admins may delete them
+	authentication = auth.New(loadUsers(), sessions(), render)
+	authentication.Private = env.Get("JCIO_MOVIEDB_PRIVATE", "false") == "true"
+	authentication.Public = append(authentication.Public, "/healthz", "/ready", "/metrics", "/robots.txt",
+		// the assets of the layout, the login page needs them too
+		"/css", "/js", "/fonts", "/images/jamesclonk_coa.png", "/favicon.ico", "/manifest.json")
+	// behind a TLS terminating proxy that doesn't set X-Forwarded-Proto, cookies have to be marked Secure explicitly
+	auth.SecureCookies = env.Get("JCIO_MOVIEDB_SECURE_COOKIES", "false") == "true"
+	authentication.Require(admin.Paths, auth.Editor)
+	authentication.Require(admin.DeletePaths, auth.Admin)
+	authentication.Routes(frontend.Router.Router)
 
 	// setup routes
-	frontend.NewRoute("/ready", ready)
-	frontend.NewRoute("/", movies)
-	frontend.NewRoute("/movies", movies)
-	frontend.NewRoute("/movie/{id}", movie)
+	frontend.Router.Handle("/healthz", health.Liveness(frontend)).Name("/healthz")
+	frontend.Router.Handle("/ready", readiness(frontend).Handler(frontend)).Name("/ready")
+	metrics.NewRoute(frontend, "/ready/degraded", page(degraded))
+	frontend.Router.Handle("/metrics", metrics.Handler()).Name("/metrics")
+	// listings have one URL per page, equivalent ones are redirected to it
+	listing := func(path string, fn web.Handler, params canonical.Params) {
+		frontend.Router.Handle(path, canonical.Redirect(params, negotiation.NewHandler(frontend, page(fn)))).Name(path)
+	}
+	listing("/", movies, movieParams)
+	listing("/movies", movies, movieParams)
+	// movies and people are linked with the slug of their title or name, /movie/1026-army-of-darkness
+	frontend.Router.Handle("/movie/{id:[0-9]+}{slug:(?:-[^/]*)?}", negotiation.NewHandler(frontend, page(movie))).Name("/movie/{id}")
+	negotiation.NewRoute(frontend, "/browse", page(browse))
 
-	frontend.NewRoute("/actors", actors)
-	frontend.NewRoute("/directors", directors)
-	frontend.NewRoute("/person/{id}", person)
+	listing("/actors", actors, peopleParams)
+	listing("/directors", directors, peopleParams)
+	frontend.Router.Handle("/person/{id:[0-9]+}{slug:(?:-[^/]*)?}", negotiation.NewHandler(frontend, page(person))).Name("/person/{id}")
 
-	frontend.NewRoute("/statistics", statistics)
+	negotiation.NewRoute(frontend, "/statistics", page(statistics))
 
-	frontend.NewRoute("/error/{.*}", createError)
+	frontend.Router.Handle("/movies.{format:csv|xlsx}", exportMovies(frontend)).Name("/movies.{format}")
+	frontend.Router.Handle("/feed.{format:atom|rss}", movieFeed(frontend)).Name("/feed.{format}")
+	frontend.Router.Handle("/suggest", suggestions(frontend)).Name("/suggest")
+	frontend.Router.Handle("/sitemap.xml", sitemapXML(frontend)).Name("/sitemap.xml")
+	frontend.Router.Handle("/sitemap-{n:[0-9]+}.xml", sitemapXML(frontend)).Name("/sitemap-{n}.xml")
+	frontend.Router.Handle("/robots.txt", robots()).Name("/robots.txt")
 
-	// setup navbar
-	frontend.SetNavigation(navbar.GetNavigation())
+	// covers are resized from their origin, a directory or a URL, and cached on disk
+	coverImages := covers.New(
+		covers.NewOrigin(env.Get("JCIO_MOVIEDB_COVERS_ORIGIN", "public/images/movies")),
+		env.Get("JCIO_MOVIEDB_COVERS_CACHE", filepath.Join(os.TempDir(), "moviedb-covers")),
+		getEnvDuration("JCIO_MOVIEDB_COVERS_TTL", 7*24*time.Hour),
+	)
+	frontend.Router.Handle("/covers/{size:thumb|card|full}/{name:[^/]*}", coverImages).Name("/covers/{size}/{name}")
+	// old links to the full size covers, unless negroni.Static finds them in public/
+	frontend.Router.Handle("/images/movies/{name}", coverImages).Name("/images/movies/{name}")
+
+	metrics.NewRoute(frontend, "/error/{.*}", page(createError))
+
+	adminSection := admin.New(uncachedSource, render, errorPage)
+	adminSection.OnChange = func(id int) {
+		if backendCache != nil {
+			backendCache.Flush()
+		}
+		moviePictures.Flush()
+		go func() {
+			searchIndex.Forget(id)
+			searchIndex.Refresh()
+		}()
+	}
+	adminSection.Routes(frontend.Router.Router)
 
-	n := negroni.Sbagliato()
+	// like negroni.Sbagliato, but the files in public/ are served after the login check,
+	// they are as private as the rest of the site
+	n := negroni.Classico()
+	n.Use(negroni.NewRecovery())
+	n.Use(negroni.NewLogger())
+	n.Use(gzip.Gzip(gzip.DefaultCompression))
+	n.Use(requestid.NewMiddleware())
+	n.Use(metrics.NewMiddleware(frontend.Router.Router))
+	n.Use(authentication)
+	n.Use(negroni.NewStatic())
 	n.UseHandler(frontend.Router)
 
 	return n
 }
 
-func getData(f func(string, string) *web.Page, urlPart string, req *http.Request) *web.Page {
-	var query string
-	if len(req.URL.RawQuery) > 0 {
-		query = "?" + req.URL.RawQuery
+// newDataSources returns the source of the movie data chosen by JCIO_MOVIEDB_DATASOURCE, both uncached
+// and cached. Either moviedb-backend over HTTP, or the sqlite database of JCIO_MOVIEDB_DATABASE embedded
+// in the frontend, which needs neither a cache nor a fallback.
+func newDataSources() (uncached, cached datasource.Source) {
+	switch mode := env.Get("JCIO_MOVIEDB_DATASOURCE", datasource.Backend); mode {
+	case datasource.Backend:
+	case datasource.Embedded:
+		db, err := datasource.OpenSQLite(env.Get("JCIO_MOVIEDB_DATABASE", "moviedb.db"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		return db, db
+	default:
+		log.Fatalf("Invalid data source: %s", mode)
 	}
-	query = fmt.Sprintf("%s%s", urlPart, query)
 
-	response, err := backendClient.Get(fmt.Sprintf("%s%s", backendUrl, query))
-	if err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+	backendClient = web.NewBackendClient()
+	client := backendClient.HttpClient()
+	// error responses become typed errors, their body never reaches the user
+	backendTransport = &breaker.Transport{
+		Next:    client.Transport,
+		Breaker: backendBreaker,
+		Timeout: backendTimeout,
+		Retries: backendRetries,
+		Backoff: retryBackoff,
 	}
-	return f(response, query)
+	client.Transport = &upstream.Transport{Next: backendTransport}
+
+	// requests are given up along with the page they are made for, this wraps all transports above
+	httpClient := datasource.NewHTTPClient(backendClient)
+	backendStale = fallback.New(metrics.NewGetter(httpClient),
+		env.Get("JCIO_MOVIEDB_FALLBACK_DIR", filepath.Join(os.TempDir(), "moviedb-frontend")),
+		getEnvInt("JCIO_MOVIEDB_FALLBACK_SIZE", 10000),
+	)
+	backendCache = cache.New(backendStale,
+		getEnvDuration("JCIO_MOVIEDB_CACHE_TTL", time.Minute),
+		getEnvDuration("JCIO_MOVIEDB_CACHE_STALE", 5*time.Minute),
+		getEnvInt("JCIO_MOVIEDB_CACHE_SIZE", 1000),
+	)
+	return datasource.NewBackend(httpClient, httpClient, backendUrl),
+		datasource.NewBackend(backendCache, httpClient, backendUrl)
 }
 
-func movies(w http.ResponseWriter, req *http.Request) *web.Page {
-	return getData(func(response, query string) *web.Page {
-		var data []moviedb.MovieListing
-		if err := json.Unmarshal([]byte(response), &data); err != nil {
-			return web.Error("Error!", http.StatusInternalServerError, err)
+// loadUsers returns the users of JCIO_MOVIEDB_USERS_FILE, plus the admin of JCIO_MOVIEDB_ADMIN_USER
+// and JCIO_MOVIEDB_ADMIN_PASSWORD, so there is always someone to log in with.
+func loadUsers() *auth.Store {
+	users := auth.NewStore()
+	if file := env.Get("JCIO_MOVIEDB_USERS_FILE", ""); len(file) > 0 {
+		var err error
+		if users, err = auth.LoadStore(file); err != nil {
+			log.Fatal(err)
 		}
-		return &web.Page{
-			ActiveLink: query,
-			Content:    data,
-			Template:   "movies",
+	}
+
+	name := env.Get("JCIO_MOVIEDB_ADMIN_USER", "admin")
+	if password := env.Get("JCIO_MOVIEDB_ADMIN_PASSWORD", ""); len(password) > 0 {
+		if _, ok := users.Get(name); !ok {
+			if err := users.Put(name, password, auth.Admin); err != nil {
+				log.Fatal(err)
+			}
 		}
-	}, "/movies", req)
+	}
+	if users.Len() == 0 {
+		log.Warn("No users configured, nobody can log in")
+	}
+	return users
 }
 
-func movie(w http.ResponseWriter, req *http.Request) *web.Page {
-	return getData(func(response, query string) *web.Page {
-		var data moviedb.Movie
-		if err := json.Unmarshal([]byte(response), &data); err != nil {
-			return web.Error("Error!", http.StatusInternalServerError, err)
-		}
-		return &web.Page{
-			Title:    fmt.Sprintf("jamesclonk.io - Movie Database - %s", data.Title),
-			Content:  data,
-			Template: "movie",
+func sessions() *auth.Sessions {
+	secret := env.Get("JCIO_MOVIEDB_SESSION_SECRET", "")
+	if len(secret) == 0 {
+		log.Warn("JCIO_MOVIEDB_SESSION_SECRET is not set, sessions won't survive a restart")
+	}
+	return auth.NewSessions([]byte(secret), getEnvDuration("JCIO_MOVIEDB_SESSION_TTL", 12*time.Hour))
+}
+
+// movieListing returns the backend listing of the movies requested by req.
+// All other query parameters are the frontend's own.
+func movieListing(req *http.Request) (*api.Listing, error) {
+	listing, err := api.ParseListing(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	if !backendPagination {
+		listing.Page = api.Page{}
+	}
+	return listing, nil
+}
+
+// movieParams returns the canonical parameters of a movie listing, see canonical.Params.
+func movieParams(values url.Values) (url.Values, error) {
+	listing, err := api.ParseListing(values)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.Merge(
+		listing.Canonical().Values(),
+		negotiation.Canonical(values),
+		listview.Canonical(values),
+		pagination.Canonical(values),
+	), nil
+}
+
+// peopleParams returns the canonical parameters of a listing of actors or directors, see canonical.Params.
+func peopleParams(values url.Values) (url.Values, error) {
+	return canonical.Merge(negotiation.Canonical(values), pagination.Canonical(values)), nil
+}
+
+// canonicalURL returns the absolute canonical URL of the page of req, without the format and view.
+// Pages without params have none at all.
+func canonicalURL(req *http.Request, params canonical.Params) string {
+	query := ""
+	if params != nil {
+		values, err := params(req.URL.Query())
+		if err != nil {
+			return ""
 		}
-	}, req.RequestURI, req)
+		values.Del("format")
+		values.Del("view")
+		query = canonical.Encode(values)
+	}
+	return baseURL(req) + canonical.Path(req.URL.Path, query)
+}
+
+// backendPage returns the page of a listing the backend should return, if it paginates at all.
+func backendPage(req *http.Request) api.Page {
+	if !backendPagination {
+		return api.Page{}
+	}
+	return api.ParsePage(req.URL.Query())
+}
+
+// paginate sets up the pagination of a listing of count items and returns the bounds of the current page.
+// If paginated is true, the backend already returned only the current page.
+func paginate(w http.ResponseWriter, req *http.Request, perPage int, data *PageData, count int, paginated bool) (start, end int) {
+	data.Pagination = pagination.New(req, perPage)
+	start, end = data.Pagination.Apply(count, paginated)
+	if link := data.Pagination.LinkHeader(); len(link) > 0 {
+		w.Header().Set("Link", link)
+	}
+	return start, end
+}
+
+func movies(w http.ResponseWriter, req *http.Request) *web.Page {
+	listing, err := movieListing(req)
+	if err != nil {
+		return errorPage(req, err)
+	}
+	pageData := &PageData{}
+	data, err := dataSource.Movies(pageData.track(req.Context()), listing)
+	if err != nil {
+		return errorPage(req, err)
+	}
+	start, end := paginate(w, req, moviesPerPage, pageData, len(data), backendPagination)
+	pageData.setView(w, req, data[start:end])
+	pageData.Canonical = canonicalURL(req, movieParams)
+	pageData.Query = canonical.Encode(listing.Values())
+	if filter := listing.Filter(); len(filter) > 0 {
+		pageData.Feeds = append(pageData.Feeds, FeedLink{"Latest Movies (filtered)", "/feed.atom?" + filter})
+	}
+	return &web.Page{
+		ActiveLink: listing.Path(),
+		Content:    data[start:end],
+		Data:       pageData,
+		Template:   "movies",
+	}
+}
+
+func movie(w http.ResponseWriter, req *http.Request) *web.Page {
+	id, err := api.ParseID(mux.Vars(req)["id"])
+	if err != nil {
+		return errorPage(req, err)
+	}
+	pageData := &PageData{}
+	data, err := dataSource.Movie(pageData.track(req.Context()), id)
+	if err != nil {
+		return errorPage(req, err)
+	}
+	if path := slug.Movie(id, data.Title); req.URL.Path != path {
+		return redirect(w, req, path)
+	}
+	pageData.Meta = meta.Movie(data, baseURL(req))
+	pageData.Canonical = canonicalURL(req, nil)
+	return &web.Page{
+		Title:    fmt.Sprintf("jamesclonk.io - Movie Database - %s", data.Title),
+		Content:  *data,
+		Data:     pageData,
+		Template: "movie",
+	}
 }
 
 func actors(w http.ResponseWriter, req *http.Request) *web.Page {
-	return getData(func(response, query string) *web.Page {
-		var data []moviedb.Person
-		if err := json.Unmarshal([]byte(response), &data); err != nil {
-			return web.Error("Error!", http.StatusInternalServerError, err)
-		}
-		return &web.Page{
-			ActiveLink: query,
-			Content:    data,
-			Template:   "people",
-		}
-	}, "/actors", req)
+	pageData := &PageData{}
+	data, err := dataSource.Actors(pageData.track(req.Context()), backendPage(req))
+	if err != nil {
+		return errorPage(req, err)
+	}
+	return people(w, req, "/actors", data, pageData)
 }
 
 func directors(w http.ResponseWriter, req *http.Request) *web.Page {
-	return getData(func(response, query string) *web.Page {
-		var data []moviedb.Person
-		if err := json.Unmarshal([]byte(response), &data); err != nil {
-			return web.Error("Error!", http.StatusInternalServerError, err)
-		}
-		return &web.Page{
-			ActiveLink: query,
-			Content:    data,
-			Template:   "people",
-		}
-	}, "/directors", req)
+	pageData := &PageData{}
+	data, err := dataSource.Directors(pageData.track(req.Context()), backendPage(req))
+	if err != nil {
+		return errorPage(req, err)
+	}
+	return people(w, req, "/directors", data, pageData)
+}
+
+// people returns the page of a listing of actors or directors.
+func people(w http.ResponseWriter, req *http.Request, activeLink string, data []moviedb.Person, pageData *PageData) *web.Page {
+	start, end := paginate(w, req, peoplePerPage, pageData, len(data), backendPagination)
+	pageData.Canonical = canonicalURL(req, peopleParams)
+	return &web.Page{
+		ActiveLink: activeLink,
+		Content:    data[start:end],
+		Data:       pageData,
+		Template:   "people",
+	}
 }
 
 func statistics(w http.ResponseWriter, req *http.Request) *web.Page {
-	return getData(func(response, query string) *web.Page {
-		var data moviedb.Statistics
-		if err := json.Unmarshal([]byte(response), &data); err != nil {
-			return web.Error("Error!", http.StatusInternalServerError, err)
-		}
-		return &web.Page{
-			Title:      "jamesclonk.io - Movie Database - Statistics",
-			ActiveLink: query,
-			Content:    data,
-			Template:   "statistics",
-		}
-	}, "/statistics", req)
+	pageData := &PageData{}
+	data, err := dataSource.Statistics(pageData.track(req.Context()))
+	if err != nil {
+		return errorPage(req, err)
+	}
+	pageData.Canonical = canonicalURL(req, nil)
+	return &web.Page{
+		Title:      "jamesclonk.io - Movie Database - Statistics",
+		ActiveLink: "/statistics",
+		Content:    *data,
+		Data:       pageData,
+		Template:   "statistics",
+	}
 }
 
-func person(w http.ResponseWriter, req *http.Request) *web.Page {
-	id := mux.Vars(req)["id"]
+// browse filters the movies by multiple facets at once, like genre=9&genre=3&score=5&year_from=1990.
+// The backend gets all filters it supports in a single query, the remaining ones are applied
+// on the full movie data of its result, which is needed to count the facet values anyway.
+func browse(w http.ResponseWriter, req *http.Request) *web.Page {
+	selection := facets.Parse(req.URL.Query())
+	pageData := &PageData{}
+	ctx := pageData.track(req.Context())
 
-	response, err := backendClient.Get(
-		fmt.Sprintf("%s/person/%s", backendUrl, id),
-	)
+	listing := api.Movies().OrderBy(api.Title, api.Asc)
+	listing.Queries = selection.BackendQuery()
+	listings, err := dataSource.Movies(ctx, listing)
 	if err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+		return errorPage(req, err)
 	}
-	var person moviedb.Person
-	if err := json.Unmarshal([]byte(response), &person); err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+	limited := len(listings) > detailsLimit
+	if limited {
+		listings = listings[:detailsLimit]
 	}
-
-	response, err = backendClient.Get(
-		fmt.Sprintf("%s/movies?query=actor&value=%s&sort=title&by=asc", backendUrl, id),
-	)
+	movies, err := movieDetails(ctx, listings)
 	if err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+		return errorPage(req, err)
 	}
-	var acting []moviedb.MovieListing
-	if err := json.Unmarshal([]byte(response), &acting); err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+
+	result := selection.Apply(movies)
+	if limited {
+		result.Limit = detailsLimit
 	}
+	start, end := paginate(w, req, moviesPerPage, pageData, len(result.Movies), false)
+	result.Movies = result.Movies[start:end]
+	return &web.Page{
+		Title:      "jamesclonk.io - Movie Database - Browse",
+		ActiveLink: "/browse",
+		Content:    result,
+		Data:       pageData,
+		Template:   "browse",
+	}
+}
 
-	response, err = backendClient.Get(
-		fmt.Sprintf("%s/movies?query=director&value=%s&sort=title&by=asc", backendUrl, id),
-	)
+func person(w http.ResponseWriter, req *http.Request) *web.Page {
+	id, err := api.ParseID(mux.Vars(req)["id"])
 	if err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+		return errorPage(req, err)
+	}
+	actorIn := api.Movies().Where(api.Actor, strconv.Itoa(id)).OrderBy(api.Title, api.Asc)
+	directorOf := api.Movies().Where(api.Director, strconv.Itoa(id)).OrderBy(api.Title, api.Asc)
+	pageData := &PageData{}
+
+	// the person and their movies are independent of each other, fetch them all at once
+	fan := fanout.New(req.Context(), pageTimeout)
+	fan.Go("person", backendCall(func(ctx context.Context) (interface{}, error) {
+		return dataSource.Person(ctx, id)
+	}))
+	fan.Go("actor_in", backendCall(func(ctx context.Context) (interface{}, error) {
+		return dataSource.Movies(ctx, actorIn)
+	}))
+	fan.Go("director_of", backendCall(func(ctx context.Context) (interface{}, error) {
+		return dataSource.Movies(ctx, directorOf)
+	}))
+	results := fan.Wait()
+
+	// render whatever succeeded, only fail if nothing did
+	failed := results.Failed()
+	if err := results.Failure("person"); upstream.Is(err, upstream.NotFound) || len(failed) == 3 {
+		return errorPage(req, err)
 	}
-	var directing []moviedb.MovieListing
-	if err := json.Unmarshal([]byte(response), &directing); err != nil {
-		return web.Error("Error!", http.StatusInternalServerError, err)
+	if len(failed) > 0 {
+		log.WithFields(logrus.Fields{
+			"error": results.Err(),
+			"id":    id,
+		}).Warn("Rendering incomplete person page")
 	}
 
-	data := struct {
-		Person     moviedb.Person
-		ActorIn    []moviedb.MovieListing
-		DirectorOf []moviedb.MovieListing
-	}{
-		Person:     person,
-		ActorIn:    acting,
-		DirectorOf: directing,
+	data := PersonDetails{Missing: failed}
+	data.Person.Id = id
+	if r, ok := results.Value("person").(*backendResult); ok {
+		data.Person = *r.value.(*moviedb.Person)
+		pageData.merge(r.data)
+	}
+	// without the person, their name and so the current slug is unknown
+	if path := slug.Person(id, data.Person.Name); len(data.Person.Name) > 0 && req.URL.Path != path {
+		return redirect(w, req, path)
+	}
+	if r, ok := results.Value("actor_in").(*backendResult); ok {
+		data.ActorIn = r.value.([]moviedb.MovieListing)
+		pageData.merge(r.data)
 	}
+	if r, ok := results.Value("director_of").(*backendResult); ok {
+		data.DirectorOf = r.value.([]moviedb.MovieListing)
+		pageData.merge(r.data)
+	}
+
+	name := data.Person.Name
+	if len(name) == 0 {
+		name = fmt.Sprintf("Person #%d", data.Person.Id)
+	}
+	if len(data.ActorIn) > 0 {
+		pageData.Feeds = append(pageData.Feeds, FeedLink{
+			fmt.Sprintf("Latest Movies with %s", name),
+			fmt.Sprintf("/feed.atom?query=actor&value=%d", data.Person.Id),
+		})
+	}
+	if len(data.DirectorOf) > 0 {
+		pageData.Feeds = append(pageData.Feeds, FeedLink{
+			fmt.Sprintf("Latest Movies by %s", name),
+			fmt.Sprintf("/feed.atom?query=director&value=%d", data.Person.Id),
+		})
+	}
+
+	pageData.setView(w, req, data.ActorIn, data.DirectorOf)
+	pageData.Canonical = canonicalURL(req, nil)
+
+	if len(data.Person.Name) > 0 {
+		pageData.Meta = meta.Person(&data.Person, len(data.ActorIn), len(data.DirectorOf), baseURL(req))
+	}
+
 	return &web.Page{
-		Title:    fmt.Sprintf("jamesclonk.io - Movie Database - %s", person.Name),
+		Title:    fmt.Sprintf("jamesclonk.io - Movie Database - %s", name),
 		Content:  data,
+		Data:     pageData,
 		Template: "person",
 	}
 }
 
-func ready(w http.ResponseWriter, req *http.Request) *web.Page {
+// backendResult is the value read by a backendCall, along with its own PageData.
+// Concurrent calls must not share one, it is merged into the page's afterwards.
+type backendResult struct {
+	value interface{}
+	data  *PageData
+}
+
+// backendCall returns a fan-out call of read, tracking the stale data it reads.
+// A call running past the deadline is cancelled along with its backend request.
+func backendCall(read func(ctx context.Context) (interface{}, error)) fanout.Call {
+	return func(ctx context.Context) (interface{}, error) {
+		data := &PageData{}
+		value, err := read(data.track(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &backendResult{value, data}, nil
+	}
+}
+
+// exportMovies writes the movie listing as CSV or XLSX file download.
+// With details=true every row is enriched by the full movie data.
+func exportMovies(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		table, err := exportTable(w, req)
+		if err != nil {
+			metrics.NewHandler(f, navigation.Handler(func(http.ResponseWriter, *http.Request) *web.Page {
+				return errorPage(req, err)
+			}))(w, req)
+			return
+		}
+
+		format := mux.Vars(req)["format"]
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="movies.%s"`, format))
+		if format == "xlsx" {
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+			err = table.WriteXLSX(w)
+		} else {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			err = table.WriteCSV(w)
+		}
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error":  err,
+				"format": format,
+			}).Error("Could not write movie export")
+		}
+	}
+}
+
+// exportTable returns the table of the movies requested by req. With details, only a page of them,
+// at most detailsLimit movies, linked to the other pages by a Link header.
+func exportTable(w http.ResponseWriter, req *http.Request) (*export.Table, error) {
+	listing, err := api.ParseListing(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	// the pages of the details export are its own, not those of the listing
+	listing.Page = api.Page{}
+	listings, err := dataSource.Movies(req.Context(), listing)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Query().Get("details") != "true" {
+		return export.MovieListings(listings), nil
+	}
+
+	page := pagination.New(req, detailsLimit)
+	if page.PerPage > detailsLimit {
+		page.PerPage = detailsLimit
+	}
+	start, end := page.Apply(len(listings), false)
+	if link := page.LinkHeader(); len(link) > 0 {
+		w.Header().Set("Link", link)
+	}
+	movies, err := movieDetails(req.Context(), listings[start:end])
+	if err != nil {
+		return nil, err
+	}
+	return export.Movies(movies), nil
+}
+
+// movieDetails returns the full movie data of all listings, in their order. They are loaded
+// by detailsConcurrency workers at once, the first failure cancels all others.
+func movieDetails(ctx context.Context, listings []moviedb.MovieListing) ([]*moviedb.Movie, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var failure error
+	fail := func(err error) {
+		once.Do(func() {
+			failure = err
+			cancel()
+		})
+	}
+
+	movies := make([]*moviedb.Movie, len(listings))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < detailsConcurrency && w < len(listings); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				movie, err := dataSource.Movie(ctx, listings[i].Id)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				movies[i] = movie
+			}
+		}()
+	}
+	for i := range listings {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// movieFeed writes the latest additions to the movie database as Atom or RSS feed.
+// It accepts the same filter parameters as the /movies listing.
+func movieFeed(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		format := mux.Vars(req)["format"]
+
+		var body []byte
+		movies, err := feedMovies(req)
+		if err == nil {
+			if format == "rss" {
+				body, err = movies.RSS()
+			} else {
+				body, err = movies.Atom()
+			}
+		}
+		if err != nil {
+			metrics.NewHandler(f, navigation.Handler(func(http.ResponseWriter, *http.Request) *web.Page {
+				return errorPage(req, err)
+			}))(w, req)
+			return
+		}
+
+		if format == "rss" {
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		}
+		w.Write(body)
+	}
+}
+
+func feedMovies(req *http.Request) (*feed.Feed, error) {
+	filter, err := api.ParseListing(req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	// only the latest movies matching the filter, regardless of the sorting and page of the listing
+	listing := api.Movies().OrderBy(api.Id, api.Desc)
+	listing.Queries = filter.Queries
+
+	stats, err := dataSource.Statistics(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	listings, err := dataSource.Movies(req.Context(), listing)
+	if err != nil {
+		return nil, err
+	}
+	if len(listings) > feedSize {
+		listings = listings[:feedSize]
+	}
+
+	base := baseURL(req)
+	movies := &feed.Feed{
+		Title:   "jamesclonk.io - Movie Database - Latest Movies",
+		Link:    base + "/movies",
+		Self:    base + req.URL.Path,
+		Updated: stats.LastUpdate,
+	}
+	if query := listing.Filter(); len(query) > 0 {
+		movies.Link += "?" + query
+		movies.Self += "?" + query
+	}
+
+	details, err := movieDetails(req.Context(), listings)
+	if err != nil {
+		return nil, err
+	}
+	for _, movie := range details {
+		// the title and so the slug of a movie may change, its ID must not
+		entry := feed.Entry{
+			ID:      fmt.Sprintf("%s/movie/%d", base, movie.Id),
+			Title:   movie.Title,
+			Link:    base + slug.Movie(movie.Id, movie.Title),
+			Summary: movie.Description,
+			Updated: stats.LastUpdate,
+		}
+		if len(movie.Picture) > 0 {
+			entry.Image = fmt.Sprintf("%s/covers/full/%s", base, movie.Picture)
+		}
+		movies.Entries = append(movies.Entries, entry)
+	}
+	return movies, nil
+}
+
+// baseURL returns the public URL of the frontend, without trailing slash.
+func baseURL(req *http.Request) string {
+	if len(frontendUrl) > 0 {
+		return strings.TrimSuffix(frontendUrl, "/")
+	}
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if proto := req.Header.Get("X-Forwarded-Proto"); len(proto) > 0 {
+		scheme = proto
+	}
+	return (&url.URL{Scheme: scheme, Host: req.Host}).String()
+}
+
+// sitemapXML writes /sitemap.xml, or one of its chunks /sitemap-{n}.xml.
+func sitemapXML(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var body []byte
+		var err error
+		if n, ok := mux.Vars(req)["n"]; ok {
+			chunk, _ := strconv.Atoi(n)
+			body, err = siteMap.Chunk(baseURL(req), chunk)
+		} else {
+			body, err = siteMap.Index(baseURL(req))
+		}
+		if err == sitemap.ErrNoChunk {
+			metrics.NewHandler(f, navigation.Handler(notFound(f.Title)))(w, req)
+			return
+		}
+		if err != nil {
+			metrics.NewHandler(f, navigation.Handler(func(http.ResponseWriter, *http.Request) *web.Page {
+				return errorPage(req, err)
+			}))(w, req)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(body)
+	}
+}
+
+// robots writes /robots.txt, pointing crawlers to the sitemap.
+func robots() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(sitemap.Robots(baseURL(req)))
+	}
+}
+
+// suggestions writes the search suggestions for the q parameter as JSON, grouped by kind.
+func suggestions(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		f.Render.JSON(w, http.StatusOK, searchIndex.Suggest(req.URL.Query().Get("q"), 5))
+	}
+}
+
+// readiness returns the checks an instance has to pass before it should get any traffic.
+func readiness(f *web.Frontend) *health.Checker {
+	checker := health.NewChecker(readyTimeout)
+	checker.Add("backend", func() error {
+		// straight to the backend, neither cached nor falling back to stale responses
+		ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+		defer cancel()
+		_, err := uncachedSource.Genres(ctx)
+		return err
+	})
+	checker.Add("circuit", func() error {
+		if state, since := backendBreaker.State(); state == breaker.Open {
+			return fmt.Errorf("circuit breaker open since %v", since)
+		}
+		return nil
+	})
+	checker.Add("templates", health.Template(f, "ready"))
+	checker.Add("navigation", func() error {
+		if len(navbar.Genres(navigation.Get())) == 0 {
+			return fmt.Errorf("genre navigation is empty")
+		}
+		return nil
+	})
+	return checker
+}
+
+func degraded(w http.ResponseWriter, req *http.Request) *web.Page {
+	// the embedded database has no fallback, it is never degraded
+	if backendStale != nil {
+		if degraded, since := backendStale.Degraded(); degraded {
+			return &web.Page{
+				Title:      "Degraded",
+				Content:    since,
+				StatusCode: http.StatusServiceUnavailable,
+				Template:   "degraded",
+			}
+		}
+	}
 	return &web.Page{
 		Title:    "Ready",
 		Content:  `{}`,
@@ -215,6 +1085,57 @@ func ready(w http.ResponseWriter, req *http.Request) *web.Page {
 	}
 }
 
+func notFound(title string) web.Handler {
+	return func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return &web.Page{
+			Title:      title,
+			StatusCode: http.StatusNotFound,
+			Data:       &PageData{RequestID: requestid.Get(req)},
+			Template:   "404",
+		}
+	}
+}
+
+// redirect returns the page permanently redirecting req to path, along with its query.
+func redirect(w http.ResponseWriter, req *http.Request, path string) *web.Page {
+	if len(req.URL.RawQuery) > 0 {
+		path += "?" + req.URL.RawQuery
+	}
+	w.Header().Set("Location", path)
+	return &web.Page{
+		StatusCode: http.StatusMovedPermanently,
+		Content:    path,
+		Template:   "redirect",
+	}
+}
+
+// errorPage logs err and returns its error page, with the status code of its upstream.Kind.
+// Users only get to see a generic message and the request ID, never the backend response.
+func errorPage(req *http.Request, err error) *web.Page {
+	e := upstream.Classify(err)
+	id := requestid.Get(req)
+
+	entry := log.WithFields(logrus.Fields{
+		"error":      err,
+		"kind":       e.Kind.String(),
+		"path":       req.URL.Path,
+		"request_id": id,
+	})
+	if e.Kind == upstream.NotFound {
+		entry.Warn("Not found")
+	} else {
+		entry.Error("Request failed")
+	}
+
+	return &web.Page{
+		Title:      "jamesclonk.io - Movie Database - Error",
+		StatusCode: e.StatusCode(),
+		Error:      e,
+		Data:       &PageData{RequestID: id},
+		Template:   "error", // renders 404 by its status code
+	}
+}
+
 func createError(w http.ResponseWriter, req *http.Request) *web.Page {
 	return web.Error(
 		"jamesclonk.io - Movie Database - Error",
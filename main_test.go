@@ -1,20 +1,34 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"image"
+	"image/jpeg"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/auth"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/breaker"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/facets"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/health"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/suggest"
 	"github.com/jamesclonk-io/stdlib/logger"
 	"github.com/jamesclonk-io/stdlib/web/negroni"
 	"github.com/stretchr/testify/assert"
 )
 
 var (
-	m *negroni.Negroni
+	m        *negroni.Negroni
+	backends *backendServer
 )
 
 func init() {
@@ -22,10 +36,195 @@ func init() {
 	logrus.SetOutput(ioutil.Discard)
 	logger.GetLogger().Out = ioutil.Discard
 
-	os.Setenv("JCIO_MOVIEDB_BACKEND", "http://moviedb-backend.jamesclonk.io")
 	os.Setenv("JCIO_HTTP_HMAC_SECRET", "who cares?")
+	backends = newBackendServer()
+
+	fallbackDir, err := ioutil.TempDir("", "moviedb-frontend")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("JCIO_MOVIEDB_BACKEND", backends.URL)
+	os.Setenv("JCIO_MOVIEDB_CACHE_TTL", "0s")
+	os.Setenv("JCIO_MOVIEDB_FALLBACK_DIR", fallbackDir)
+	os.Setenv("JCIO_MOVIEDB_BACKEND_RETRY_BACKOFF", "1ms")
+	// tests failing the backend must not open the circuit for the ones after them
+	os.Setenv("JCIO_MOVIEDB_BREAKER_THRESHOLD", "0")
+	os.Setenv("JCIO_MOVIEDB_ADMIN_PASSWORD", "adminpw")
+	os.Setenv("JCIO_MOVIEDB_COVERS_ORIGIN", coverOrigin())
+	os.Setenv("JCIO_MOVIEDB_COVERS_CACHE", filepath.Join(fallbackDir, "covers"))
+	backendUrl = backends.URL
 
 	m = setup()
+	for name, role := range map[string]auth.Role{"editor": auth.Editor, "viewer": auth.Viewer} {
+		if err := authentication.Users.Put(name, name+"pw", role); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// coverOrigin returns a directory with the cover of Army of Darkness.
+func coverOrigin() string {
+	dir, err := ioutil.TempDir("", "moviedb-covers")
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Create(filepath.Join(dir, "army_of_darkness.jpg"))
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if err := jpeg.Encode(file, image.NewGray(image.Rect(0, 0, 300, 450)), nil); err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+func Test_Main_BackendRequiresHMAC(t *testing.T) {
+	response, err := http.Get(backends.URL + "/movies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func Test_Main_BackendError(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.Fail(http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=year&value=1999", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<div class="alert alert-danger">Error: The movie database failed to answer.</div>`)
+	assert.Contains(t, body, `Request ID: <code>`+response.Header().Get("X-Request-ID")+`</code>`)
+	// the backend response is not passed on
+	assert.NotContains(t, body, "fake backend failure")
+}
+
+func Test_Main_BackendTimeout(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.Hang()
+	backendClient.HttpClient().Timeout = 50 * time.Millisecond
+	defer func() { backendClient.HttpClient().Timeout = 0 }()
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=year&value=1998", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<div class="alert alert-danger">Error: The movie database took too long to answer.</div>`)
+	assert.NotContains(t, body, backendUrl)
+}
+
+func Test_Main_BackendCanceled(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "http://localhost:3008/statistics", nil))
+	degraded, _ := backendStale.Degraded()
+	assert.False(t, degraded)
+
+	hanging := backend.Hang()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=year&value=1992", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the backend request is given up along with the page, which tells nothing about the backend
+	served := make(chan struct{})
+	go func() {
+		m.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+		close(served)
+	}()
+	<-hanging
+	cancel()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend request was not given up")
+	}
+	degraded, _ = backendStale.Degraded()
+	assert.False(t, degraded)
+	state, _ := backendBreaker.State()
+	assert.Equal(t, breaker.Closed, state)
+}
+
+func Test_Main_BackendStale(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/1026-army-of-darkness", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/1026-army-of-darkness"
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NotContains(t, response.Body.String(), `may be out of date`)
+
+	backend.Fail(http.StatusBadGateway)
+
+	response = httptest.NewRecorder()
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<title>jamesclonk.io - Movie Database - Army of Darkness</title>`)
+	assert.Contains(t, body, `may be out of date`)
+
+	// API clients get a warning instead
+	response = httptest.NewRecorder()
+	req.Header.Set("Accept", "application/json")
+	m.ServeHTTP(response, req)
+	req.Header.Del("Accept")
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `110 - "Response is Stale"`, response.Header().Get("Warning"))
+	assert.Contains(t, response.Body.String(), `"title": "Army of Darkness"`)
+
+	response = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://localhost:3008/ready/degraded", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Contains(t, response.Body.String(), `Degraded..`)
+
+	backend.Reset()
+
+	response = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://localhost:3008/movie/1026-army-of-darkness", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/1026-army-of-darkness"
+	m.ServeHTTP(response, req)
+
+	response = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://localhost:3008/ready/degraded", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `Ready..`)
 }
 
 func Test_Main_404(t *testing.T) {
@@ -76,7 +275,7 @@ func Test_Main_Index(t *testing.T) {
 	assert.Contains(t, body, `<td style="width:5%"><a class="no-underline" href="/movies?query=year&value=2010"><span class="label label-default">2010</span></a></td>`)
 	assert.Contains(t, body, `<td style="width:4%"><a class="no-underline" href="/movies?query=rating&value=16"><span class="label label-warning">16</span></a></td>`)
 	assert.Contains(t, body, `<td style="width:5%"><a class="no-underline score" href="/movies?query=score&value=4&sort=title&by=asc"><strong>★★★★</strong></a></td>`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/1026">Army of Darkness</a></td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/1026-army-of-darkness">Army of Darkness</a></td>`)
 }
 
 func Test_Main_MovieSort(t *testing.T) {
@@ -104,7 +303,7 @@ func Test_Main_MovieSort(t *testing.T) {
       <td style="width:5%"><a class="no-underline" href="/movies?query=year&value=1962"><span class="label label-default">1962</span></a></td>
       <td style="width:4%"><a class="no-underline" href="/movies?query=rating&value=16"><span class="label label-warning">16</span></a></td>
       <td style="width:5%"><a class="no-underline score" href="/movies?query=score&value=4&sort=title&by=asc"><strong>★★★★</strong></a></td>
-      <td><a class="no-underline" href="/movie/130">James Bond 007: Dr. No</a></td>
+      <td><a class="no-underline" href="/movie/130-james-bond-007-dr-no">James Bond 007: Dr. No</a></td>
     </tr>`)
 }
 
@@ -123,7 +322,7 @@ func Test_Main_OneStarMovies(t *testing.T) {
 	assert.NotContains(t, body, `★★★★`)
 	assert.NotContains(t, body, `★★★`)
 	assert.NotContains(t, body, `★★`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/451">Eragon</a></td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/451-eragon">Eragon</a></td>`)
 }
 
 func Test_Main_TitleZMovies(t *testing.T) {
@@ -158,11 +357,11 @@ func Test_Main_GenreMusicMovies(t *testing.T) {
 
 func Test_Main_Movie(t *testing.T) {
 	response := httptest.NewRecorder()
-	req, err := http.NewRequest("GET", "http://localhost:3008/movie/511", nil)
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/511-apocalypse-now", nil)
 	if err != nil {
 		t.Error(err)
 	}
-	req.RequestURI = "/movie/511"
+	req.RequestURI = "/movie/511-apocalypse-now"
 
 	m.ServeHTTP(response, req)
 	assert.Equal(t, http.StatusOK, response.Code)
@@ -171,22 +370,22 @@ func Test_Main_Movie(t *testing.T) {
 	assert.Contains(t, body, `<title>jamesclonk.io - Movie Database - Apocalypse Now</title>`)
 	assert.Contains(t, body, `<h3 class="list-group-item-heading">Apocalypse Now</h3>`)
 	assert.Contains(t, body, `<p class="list-group-item-text">Apocalypse Now Redux</p>`)
-	assert.Contains(t, body, `<img src="/images/movies/apocalypse_now.jpg" alt="Apocalypse Now"`)
+	assert.Contains(t, body, `<img src="/covers/card/apocalypse_now.jpg" srcset="/covers/card/apocalypse_now.jpg 1x, /covers/full/apocalypse_now.jpg 2x" alt="Apocalypse Now"`)
 	assert.Contains(t, body, `<a class="no-underline score" href="/movies?query=score&value=4&sort=title&by=asc"><strong>★★★★</strong></a>`)
 	assert.Contains(t, body, `<a class="no-underline" href="/movies?query=genre&value=6&sort=title&by=asc">Drama</a>, <a class="no-underline" href="/movies?query=genre&value=15&sort=title&by=asc">War</a>`)
 	assert.Contains(t, body, `<a class="no-underline" href="/movies?query=language&value=1&sort=title&by=asc">Deutsch</a>, <a class="no-underline" href="/movies?query=language&value=2&sort=title&by=asc">Englisch</a>`)
 	assert.Contains(t, body, `It is the height of the war in Vietnam, and U.S. Army Captain Willard is sent by Colonel Lucas and a General to carry out a mission that, officially, &#039;does not exist - nor will it ever exist&#039;.`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/person/1221">Albert Hall</a>, <a class="no-underline" href="/person/1224">Bo Byers</a>, <a class="no-underline" href="/person/1075">Dennis Hopper</a>, <a class="no-underline" href="/person/1219">Frederic Forrest</a>, <a class="no-underline" href="/person/1222">G.D. Spradlin</a>, <a class="no-underline" href="/person/489">Harrison Ford</a>, <a class="no-underline" href="/person/1225">James Keane</a>, <a class="no-underline" href="/person/1223">Jerry Ziesmer</a>, <a class="no-underline" href="/person/1226">Kerry Rossall</a>, <a class="no-underline" href="/person/50">Laurence Fishburne</a>, <a class="no-underline" href="/person/1217">Marlon Brando</a>, <a class="no-underline" href="/person/852">Martin Sheen</a>, <a class="no-underline" href="/person/1218">Robert Duvall</a>, <a class="no-underline" href="/person/1220">Sam Bottoms</a>, <a class="no-underline" href="/person/892">Scott Glenn</a>, </td>`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/person/1216">Francis Ford Coppola</a>, </td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/person/1221-albert-hall">Albert Hall</a>, <a class="no-underline" href="/person/1224-bo-byers">Bo Byers</a>, <a class="no-underline" href="/person/1075-dennis-hopper">Dennis Hopper</a>, <a class="no-underline" href="/person/1219-frederic-forrest">Frederic Forrest</a>, <a class="no-underline" href="/person/1222-g-d-spradlin">G.D. Spradlin</a>, <a class="no-underline" href="/person/489-harrison-ford">Harrison Ford</a>, <a class="no-underline" href="/person/1225-james-keane">James Keane</a>, <a class="no-underline" href="/person/1223-jerry-ziesmer">Jerry Ziesmer</a>, <a class="no-underline" href="/person/1226-kerry-rossall">Kerry Rossall</a>, <a class="no-underline" href="/person/50-laurence-fishburne">Laurence Fishburne</a>, <a class="no-underline" href="/person/1217-marlon-brando">Marlon Brando</a>, <a class="no-underline" href="/person/852-martin-sheen">Martin Sheen</a>, <a class="no-underline" href="/person/1218-robert-duvall">Robert Duvall</a>, <a class="no-underline" href="/person/1220-sam-bottoms">Sam Bottoms</a>, <a class="no-underline" href="/person/892-scott-glenn">Scott Glenn</a>, </td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/person/1216-francis-ford-coppola">Francis Ford Coppola</a>, </td>`)
 }
 
 func Test_Main_Person(t *testing.T) {
 	response := httptest.NewRecorder()
-	req, err := http.NewRequest("GET", "http://localhost:3008/person/211", nil)
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/211-quentin-tarantino", nil)
 	if err != nil {
 		t.Error(err)
 	}
-	req.RequestURI = "/person/211"
+	req.RequestURI = "/person/211-quentin-tarantino"
 
 	m.ServeHTTP(response, req)
 	assert.Equal(t, http.StatusOK, response.Code)
@@ -195,9 +394,170 @@ func Test_Main_Person(t *testing.T) {
 	assert.Contains(t, body, `<title>jamesclonk.io - Movie Database - Quentin Tarantino</title>`)
 	assert.Contains(t, body, `<h3 style="margin-bottom: 20px;">Quentin Tarantino</h3>`)
 	assert.Contains(t, body, `<a href="#" class="list-group-item active"><h4 class="list-group-item-heading">Actor in:</h4></a>`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/51">From Dusk Till Dawn</a></td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/51-from-dusk-till-dawn">From Dusk Till Dawn</a></td>`)
 	assert.Contains(t, body, `<a href="#" class="list-group-item active"><h4 class="list-group-item-heading">Director of:</h4></a>`)
-	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/98">Kill Bill Vol.1</a></td>`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/98-kill-bill-vol-1">Kill Bill Vol.1</a></td>`)
+}
+
+func Test_Main_PersonPartialFailure(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.FailURL("query=director&value=1216", http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/1216-francis-ford-coppola", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<h3 style="margin-bottom: 20px;">Francis Ford Coppola</h3>`)
+	assert.Contains(t, body, `<div class="alert alert-warning">Parts of this page could not be loaded, please try again later.</div>`)
+	assert.NotContains(t, body, `Director of:`)
+}
+
+func Test_Main_PersonPartialFailureJSON(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.FailURL("/person/1075", http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/1075?format=json", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var data PersonDetails
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &data))
+	assert.Equal(t, 1075, data.Person.Id)
+	assert.Equal(t, "", data.Person.Name)
+	assert.Equal(t, []string{"person"}, data.Missing)
+	assert.NotEmpty(t, data.ActorIn)
+}
+
+func Test_Main_PersonFailure(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.FailURL("1224", http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/1224", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+	assert.Contains(t, response.Body.String(), `<div class="alert alert-danger">Error: `)
+}
+
+func Test_Main_MovieMeta(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/1026-army-of-darkness", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/1026-army-of-darkness"
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<meta property="og:type" content="video.movie">`)
+	assert.Contains(t, body, `<meta property="og:title" content="Army of Darkness (1992)">`)
+	assert.Contains(t, body, `<meta property="og:url" content="http://localhost:3008/movie/1026-army-of-darkness">`)
+	assert.Contains(t, body, `<meta property="og:image" content="http://localhost:3008/covers/full/army_of_darkness.jpg">`)
+	assert.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+	assert.NotContains(t, body, `<meta name="description" content="jamesclonk.io">`)
+
+	start := strings.Index(body, `<script type="application/ld+json">`)
+	end := strings.Index(body[start:], `</script>`)
+	if assert.True(t, start > 0 && end > 0) {
+		var ld map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(body[start+len(`<script type="application/ld+json">`):start+end]), &ld))
+		assert.Equal(t, "Movie", ld["@type"])
+		assert.Equal(t, "Army of Darkness", ld["name"])
+		assert.Equal(t, "PT1H21M", ld["duration"])
+		assert.Equal(t, "FSK 16", ld["contentRating"])
+	}
+}
+
+func Test_Main_PersonMeta(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/211-quentin-tarantino", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<meta property="og:type" content="profile">`)
+	assert.Contains(t, body, `<meta name="twitter:title" content="Quentin Tarantino">`)
+	assert.Contains(t, body, `"@type":"Person","name":"Quentin Tarantino"`)
+}
+
+func Test_Main_NoMeta(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/statistics", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	body := response.Body.String()
+	assert.Contains(t, body, `<meta name="description" content="jamesclonk.io">`)
+	assert.NotContains(t, body, `og:type`)
+}
+
+func Test_Main_Sitemap(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/sitemap.xml", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", response.Header().Get("Content-Type"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, body, `<loc>http://localhost:3008/movies</loc>`)
+	assert.Contains(t, body, `<loc>http://localhost:3008/movie/1026-army-of-darkness</loc>`)
+	assert.Contains(t, body, `<loc>http://localhost:3008/person/211-quentin-tarantino</loc>`)
+	assert.Contains(t, body, `<lastmod>2015-06-14T00:00:00Z</lastmod>`)
+}
+
+func Test_Main_SitemapChunkNotFound(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/sitemap-1.xml", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func Test_Main_Robots(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/robots.txt", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "Disallow: /admin\n")
+	assert.Contains(t, response.Body.String(), "Sitemap: http://localhost:3008/sitemap.xml\n")
 }
 
 func Test_Main_Actors(t *testing.T) {
@@ -212,9 +572,9 @@ func Test_Main_Actors(t *testing.T) {
 
 	body := response.Body.String()
 	assert.Contains(t, body, `<title>jamesclonk.io - Movie Database</title>`)
-	assert.Contains(t, body, `<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/171">Robert De Niro</a></div>
-<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/778">Robert Downey Jr.</a></div>
-<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/1218">Robert Duvall</a></div>`)
+	assert.Contains(t, body, `<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/171-robert-de-niro">Robert De Niro</a></div>
+<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/778-robert-downey-jr">Robert Downey Jr.</a></div>
+<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/1218-robert-duvall">Robert Duvall</a></div>`)
 }
 
 func Test_Main_Directors(t *testing.T) {
@@ -229,9 +589,9 @@ func Test_Main_Directors(t *testing.T) {
 
 	body := response.Body.String()
 	assert.Contains(t, body, `<title>jamesclonk.io - Movie Database</title>`)
-	assert.Contains(t, body, `<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/507">Yuen Wo Ping</a></div>
-<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/512">Yu Wang</a></div>
-<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/535">Zack Snyder</a></div>`)
+	assert.Contains(t, body, `<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/507-yuen-wo-ping">Yuen Wo Ping</a></div>
+<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/512-yu-wang">Yu Wang</a></div>
+<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/535-zack-snyder">Zack Snyder</a></div>`)
 }
 
 func Test_Main_Statistics(t *testing.T) {
@@ -249,8 +609,1008 @@ func Test_Main_Statistics(t *testing.T) {
 	assert.Contains(t, body, `<td># Average Movies per day</td>`)
 	assert.Contains(t, body, `<div id="top5actorsanddirectors"></div>`)
 	assert.Contains(t, body, `var result = '<a href="/movies?query=score&value='+score+'&sort=title&by=asc" class="score no-underline">';`)
-	assert.Contains(t, body, `\x22id\x22:396,\x22name\x22:\x22Clint Eastwood\x22`)
-	assert.Contains(t, body, `\x22id\x22:493,\x22name\x22:\x22Kenji Kamiyama\x22`)
-	assert.Contains(t, body, `\x22id\x22:483,\x22name\x22:\x22Bud Spencer\x22`)
-	assert.Contains(t, body, `\x22count\x22`)
+	assert.Contains(t, body, `\u0022id\u0022:396,\u0022name\u0022:\u0022Clint Eastwood\u0022`)
+	assert.Contains(t, body, `\u0022id\u0022:493,\u0022name\u0022:\u0022Kenji Kamiyama\u0022`)
+	assert.Contains(t, body, `\u0022id\u0022:483,\u0022name\u0022:\u0022Bud Spencer\u0022`)
+	assert.Contains(t, body, `\u0022count\u0022`)
+}
+
+func Test_Main_MoviesPagination(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?sort=year&by=asc&page=2&per_page=5", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `</movies?sort=year&by=asc&page=1&per_page=5>; rel="first", </movies?sort=year&by=asc&page=1&per_page=5>; rel="prev", </movies?sort=year&by=asc&page=3&per_page=5>; rel="next", </movies?sort=year&by=asc&page=4&per_page=5>; rel="last"`, response.Header().Get("Link"))
+
+	body := response.Body.String()
+	assert.NotContains(t, body, `James Bond 007: Dr. No`)
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/200-heat">Heat</a></td>`)
+	assert.Contains(t, body, `<li><a href="/movies?sort=year&amp;by=asc&amp;page=3&amp;per_page=5" rel="next" aria-label="Next">&raquo;</a></li>`)
+	assert.Contains(t, body, `<li class='active'><a href="/movies?sort=year&amp;by=asc&amp;page=2&amp;per_page=5">2</a></li>`)
+}
+
+func Test_Main_ActorsPagination(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/actors?page=3&per_page=10", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Link"), `</actors?page=2&per_page=10>; rel="prev"`)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<div class="col-md-3 col-sm-4"><a class="no-underline" href="/person/211-quentin-tarantino">Quentin Tarantino</a></div>`)
+	assert.NotContains(t, body, `Al Pacino`)
+}
+
+func Test_Main_MovieJSON(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/511-apocalypse-now", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/511-apocalypse-now"
+	req.Header.Set("Accept", "application/json")
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json; charset=UTF-8", response.Header().Get("Content-Type"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `"title": "Apocalypse Now"`)
+	assert.NotContains(t, body, `<html`)
+}
+
+func Test_Main_MoviesXML(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=score&value=1&format=xml", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "text/xml")
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<movies><movie id="451"><title>Eragon</title><year>2006</year><score>1</score><rating>12</rating></movie></movies>`)
+}
+
+func Test_Main_PersonXML(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/211-quentin-tarantino?format=xml", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<person id="211"><name>Quentin Tarantino</name><actor_in><movie id="51">`)
+	assert.Contains(t, body, `<director_of><movie id="98"><title>Kill Bill Vol.1</title>`)
+}
+
+func Test_Main_ErrorJSON(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.Fail(http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=year&value=1997&format=json", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "application/json")
+
+	var message string
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &message))
+	assert.Equal(t, "The movie database failed to answer.", message)
+}
+
+func Test_Main_MovieNotFound(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/99999", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/99999"
+	req.Header.Set("X-Request-ID", "test-movie-not-found")
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "test-movie-not-found", response.Header().Get("X-Request-ID"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<div class="alert alert-warning">This is not the page you are looking for..</div>`)
+	assert.Contains(t, body, `Request ID: <code>test-movie-not-found</code>`)
+	assert.NotContains(t, body, "sql: no rows")
+
+	degraded, _ := backendStale.Degraded()
+	assert.False(t, degraded)
+}
+
+func Test_Main_PersonNotFound(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/99999?format=json", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func Test_Main_RouteNotFound(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/nothing/here", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	id := response.Header().Get("X-Request-ID")
+	assert.NotEmpty(t, id)
+	assert.Contains(t, response.Body.String(), `Request ID: <code>`+id+`</code>`)
+}
+
+func Test_Main_InvalidBackendRequest(t *testing.T) {
+	// invalid requests never reach the backend, they would fail with a 502
+	backend, done := useBackend()
+	defer done()
+	backend.Fail(http.StatusInternalServerError)
+
+	for _, path := range []string{
+		"/movie/abc",
+		"/movie/0",
+		"/movie/1026;drop",
+		"/person/211abc",
+		"/movies?query=id&value=1",
+		"/movies?query=genre&value=horror",
+		"/movies?query=title",
+		"/movies?sort=title&by=random",
+		"/export.csv?sort=picture&by=asc",
+		"/feed.atom?query=actor&value=x",
+	} {
+		response := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:3008"+path, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		req.RequestURI = path
+
+		m.ServeHTTP(response, req)
+		assert.Equal(t, http.StatusNotFound, response.Code, path)
+	}
+}
+
+func Test_Main_CanonicalRedirect(t *testing.T) {
+	for path, location := range map[string]string{
+		"/movies?sort=title&by=asc&query=genre&value=23":                      "/movies?query=genre&value=23&sort=title&by=asc",
+		"/movies?query=genre&value=23&query=genre&value=23&sort=title&by=asc": "/movies?query=genre&value=23&sort=title&by=asc",
+		"/movies?sort=title&by=asc&sort=title&by=desc&utm_source=feed":        "/movies?sort=title&by=asc",
+		"/movies?page=2&per_page=10&format=JSON&view=grid&sort=year&by=desc":  "/movies?sort=year&by=desc&format=json&view=grid&page=2&per_page=10",
+		"/?page=abc&view=cards":                                                  "/",
+		"/actors?per_page=10&page=3&sort=name":                                   "/actors?page=3&per_page=10",
+		"/directors?format=xml&foo=bar":                                          "/directors?format=xml",
+		"/movies?query=format&value=16%3a9&sort=title&by=asc&sort=title&by=desc": "/movies?query=format&value=16%3A9&sort=title&by=asc",
+	} {
+		response := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:3008"+path, nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		m.ServeHTTP(response, req)
+		assert.Equal(t, http.StatusMovedPermanently, response.Code, path)
+		assert.Equal(t, location, response.Header().Get("Location"), path)
+	}
+
+	// links within the site are canonical already
+	for _, path := range []string{
+		"/movies?query=format&value=16%3a9&sort=title&by=asc",
+		"/movies?sort=score&by=desc&sort=title&by=asc",
+		"/movies?query=genre&value=23",
+		"/actors?page=2&per_page=10",
+	} {
+		response := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:3008"+path, nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		m.ServeHTTP(response, req)
+		assert.Equal(t, http.StatusOK, response.Code, path)
+	}
+}
+
+func Test_Main_SlugRedirect(t *testing.T) {
+	for path, location := range map[string]string{
+		"/movie/1026":                  "/movie/1026-army-of-darkness",
+		"/movie/1026-evil-dead-3":      "/movie/1026-army-of-darkness",
+		"/movie/1026-":                 "/movie/1026-army-of-darkness",
+		"/movie/1026?format=json":      "/movie/1026-army-of-darkness?format=json",
+		"/person/211":                  "/person/211-quentin-tarantino",
+		"/person/211-tarantino?view=1": "/person/211-quentin-tarantino?view=1",
+	} {
+		response := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:3008"+path, nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		m.ServeHTTP(response, req)
+		assert.Equal(t, http.StatusMovedPermanently, response.Code, path)
+		assert.Equal(t, location, response.Header().Get("Location"), path)
+	}
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/1026-army-of-darkness", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "Army of Darkness")
+}
+
+func Test_Main_CanonicalLink(t *testing.T) {
+	for path, canonical := range map[string]string{
+		"/movies?query=score&value=5&sort=title&by=asc&view=table&page=2&per_page=5": "http://localhost:3008/movies?query=score&amp;value=5&amp;sort=title&amp;by=asc&amp;page=2&amp;per_page=5",
+		"/actors":                                 "http://localhost:3008/actors",
+		"/movie/1026-army-of-darkness":            "http://localhost:3008/movie/1026-army-of-darkness",
+		"/person/211-quentin-tarantino":           "http://localhost:3008/person/211-quentin-tarantino",
+		"/person/211-quentin-tarantino?view=grid": "http://localhost:3008/person/211-quentin-tarantino",
+		"/statistics":                             "http://localhost:3008/statistics",
+	} {
+		response := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://localhost:3008"+path, nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		m.ServeHTTP(response, req)
+		assert.Equal(t, http.StatusOK, response.Code, path)
+		assert.Contains(t, response.Body.String(), `<link rel="canonical" href="`+canonical+`">`, path)
+	}
+}
+
+func Test_Main_ExportCSV(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies.csv?query=genre&value=23&sort=title&by=asc&page=2&per_page=1", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="movies.csv"`, response.Header().Get("Content-Disposition"))
+	assert.Equal(t, "Id,Title,Year,Score,Rating\n51,From Dusk Till Dawn,1996,3,18\n200,Heat,1995,5,16\n98,Kill Bill Vol.1,2003,4,18\n", response.Body.String())
+}
+
+func Test_Main_ExportCSVDetails(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies.csv?query=score&value=1&details=true", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "Id,Title,Year,Score,Rating,Alttitle,Format,Type,Region,Disks,Length,Languages,Genres,Actors,Directors\n"+
+		"451,Eragon,2006,1,12,,16:9,DVD,2,1,104,\"Deutsch, Englisch\",Fantasy,\"Ed Speleers, Jeremy Irons\",Stefen Fangmeier\n", response.Body.String())
+}
+
+func Test_Main_ExportCSVDetailsPages(t *testing.T) {
+	defer func(limit int) { detailsLimit = limit }(detailsLimit)
+	detailsLimit = 1
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies.csv?query=genre&value=23&details=true&per_page=100", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Link"), "page=2")
+	// the header and a single movie, however many were asked for
+	assert.Equal(t, 2, strings.Count(response.Body.String(), "\n"))
+}
+
+func Test_Main_ExportLinks(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies?query=genre&value=23&sort=year&by=asc&view=grid&page=2&per_page=1", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	// exports and feeds are of the whole listing, however it is shown
+	body := response.Body.String()
+	assert.Contains(t, body, `href="/movies.csv?query=genre&amp;value=23&amp;sort=year&amp;by=asc"`)
+	assert.Contains(t, body, `href="/movies.xlsx?query=genre&amp;value=23&amp;sort=year&amp;by=asc&amp;details=true"`)
+	assert.Contains(t, body, `href="/feed.rss?query=genre&amp;value=23&amp;sort=year&amp;by=asc"`)
+	assert.NotContains(t, body, `.xlsx?query=genre&amp;value=23&amp;sort=year&amp;by=asc&amp;page=`)
+}
+
+func Test_Main_ExportXLSX(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movies.xlsx?query=score&value=1", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", response.Header().Get("Content-Type"))
+	assert.Equal(t, "PK", response.Body.String()[:2])
+}
+
+func Test_Main_FeedAtom(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/feed.atom", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", response.Header().Get("Content-Type"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<link href="http://localhost:3008/feed.atom" rel="self" type="application/atom+xml"></link>`)
+	assert.Contains(t, body, `<updated>2015-06-14T00:00:00Z</updated>`)
+	assert.Contains(t, body, `<title>Army of Darkness</title>`)
+	assert.Contains(t, body, `<id>http://localhost:3008/movie/1026</id>`)
+	assert.Contains(t, body, `<link href="http://localhost:3008/movie/1026-army-of-darkness" rel="alternate" type="text/html"></link>`)
+	assert.Contains(t, body, `&lt;img src=&#34;http://localhost:3008/covers/full/army_of_darkness.jpg&#34;`)
+	assert.True(t, strings.Index(body, "Army of Darkness") < strings.Index(body, "Argo"))
+}
+
+func Test_Main_FeedRSSFiltered(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/feed.rss?query=director&value=211&sort=title&by=asc&page=2", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", response.Header().Get("Content-Type"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<atom:link href="https://localhost:3008/feed.rss?query=director&amp;value=211" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, body, `<link>https://localhost:3008/movie/98-kill-bill-vol-1</link>`)
+	assert.Contains(t, body, `<guid>https://localhost:3008/movie/98</guid>`)
+	assert.Contains(t, body, `<enclosure url="https://localhost:3008/covers/full/kill_bill_vol1.jpg" length="0" type="image/jpeg"></enclosure>`)
+	assert.NotContains(t, body, "Army of Darkness")
+}
+
+func Test_Main_FeedLinks(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/person/211-quentin-tarantino", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `<link rel="alternate" type="application/atom+xml" title="Latest Movies" href="/feed.atom">`)
+	assert.Contains(t, response.Body.String(), `<link rel="alternate" type="application/atom+xml" title="Latest Movies by Quentin Tarantino" href="/feed.atom?query=director&amp;value=211">`)
+}
+
+func Test_Main_Suggest(t *testing.T) {
+	assert.NoError(t, searchIndex.Refresh())
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/suggest?q=tarantnio", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Header().Get("Content-Type"), "application/json")
+
+	var groups []suggest.Group
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &groups))
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, "Actors", groups[0].Name)
+	assert.Equal(t, "Directors", groups[1].Name)
+	assert.Equal(t, suggest.Suggestion{Name: "Quentin Tarantino", URL: "/person/211-quentin-tarantino"}, groups[1].Suggestions[0])
+
+	response = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://localhost:3008/suggest?q=zatoichi", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &groups))
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, suggest.Suggestion{Name: "Zatôichi", Detail: "2003", URL: "/movie/300-zatoichi"}, groups[0].Suggestions[0])
+}
+
+func Test_Main_Browse(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/browse?genre=23&score=4&score=5&year_from=1995&year_to=2003", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	body := response.Body.String()
+	assert.Contains(t, body, `<a class="no-underline" href="/movie/200-heat">Heat</a>`)
+	assert.Contains(t, body, `<a class="no-underline" href="/movie/98-kill-bill-vol-1">Kill Bill Vol.1</a>`)
+	assert.NotContains(t, body, `From Dusk Till Dawn`)
+	assert.Contains(t, body, `<input type="checkbox" name="genre" value="23" onchange="this.form.submit()" checked>`)
+	assert.Contains(t, body, `name="year_from" min="1995" max="2003" placeholder="1995" value="1995"`)
+}
+
+func Test_Main_BrowseJSON(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/browse?genre=23&score=4&score=5&format=json", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var result facets.Result
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	assert.Equal(t, 2, len(result.Movies))
+
+	var scores *facets.Facet
+	for _, facet := range result.Facets {
+		if facet.Name == "score" {
+			scores = facet
+		}
+	}
+	// scores are counted as if not selected, all other facets apply
+	assert.Equal(t, []facets.Value{
+		{Value: "3", Label: "✰✰✰", Count: 1},
+		{Value: "4", Label: "✰✰✰✰", Count: 1, Selected: true},
+		{Value: "5", Label: "✰✰✰✰✰", Count: 1, Selected: true},
+	}, scores.Values)
+}
+
+func Test_Main_BrowseLimit(t *testing.T) {
+	defer func(limit int) { detailsLimit = limit }(detailsLimit)
+	detailsLimit = 1
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/browse?genre=23&format=json", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var result facets.Result
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Limit)
+	assert.Equal(t, 1, len(result.Movies))
+}
+
+func Test_Main_Metrics(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/1026-army-of-darkness", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	m.ServeHTTP(httptest.NewRecorder(), req)
+	req.Header.Set("Accept", "application/json")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	response := httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "http://localhost:3008/metrics", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header().Get("Content-Type"))
+
+	body := response.Body.String()
+	assert.Contains(t, body, `moviedb_frontend_http_requests_total{route="/movie/{id}",method="GET",code="200"}`)
+	assert.Contains(t, body, `moviedb_frontend_http_request_duration_seconds_bucket{route="/movie/{id}",method="GET",le="+Inf"}`)
+	assert.Contains(t, body, `moviedb_frontend_backend_requests_total{path="/movie/{id}",result="success"}`)
+	assert.Contains(t, body, `moviedb_frontend_template_render_duration_seconds_count{template="movie"}`)
+	assert.Contains(t, body, `moviedb_frontend_template_render_duration_seconds_count{template="movie.json"}`)
+	assert.Contains(t, body, `# TYPE moviedb_frontend_cache_hits_total counter`)
+}
+
+func Test_Main_Healthz(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.Fail(http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/healthz", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `"status": "ok"`)
+}
+
+func Test_Main_Ready(t *testing.T) {
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/ready", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, health.OK, report.Status)
+	assert.Equal(t, 4, len(report.Checks))
+	for name, check := range report.Checks {
+		assert.Equal(t, health.OK, check.Status, name)
+	}
+}
+
+func Test_Main_ReadyBackendDown(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.Fail(http.StatusInternalServerError)
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/ready", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, health.Fail, report.Status)
+	assert.Equal(t, health.Fail, report.Checks["backend"].Status)
+	assert.Equal(t, health.OK, report.Checks["circuit"].Status)
+	assert.Equal(t, health.OK, report.Checks["templates"].Status)
+	assert.Equal(t, health.OK, report.Checks["navigation"].Status)
+}
+
+func Test_Main_CircuitBreaker(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	previous := backendBreaker
+	backendBreaker = breaker.New(1, time.Minute)
+	backendTransport.Breaker = backendBreaker
+	defer func() {
+		backendBreaker = previous
+		backendTransport.Breaker = previous
+	}()
+
+	backend.Fail(http.StatusInternalServerError)
+	_, err := backendClient.Get(backendUrl + "/genres")
+	assert.Error(t, err)
+	backend.Reset()
+
+	// the backend is back, but the circuit stays open until the cooldown is over
+	_, err = backendClient.Get(backendUrl + "/genres")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), breaker.ErrOpen.Error())
+
+	response := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/ready", nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, health.Fail, report.Checks["circuit"].Status)
+	assert.Contains(t, report.Checks["circuit"].Error, "circuit breaker open since")
+}
+
+func Test_Main_BackendRetry(t *testing.T) {
+	backend, done := useBackend()
+	defer done()
+	backend.FailTimes(2, http.StatusBadGateway)
+
+	_, err := backendClient.Get(backendUrl + "/genres")
+	assert.NoError(t, err)
+}
+
+var sessionCookies = make(map[string]*http.Cookie)
+
+// login logs the user called name in, and returns its session cookie.
+func login(t *testing.T, name string) *http.Cookie {
+	if cookie, ok := sessionCookies[name]; ok {
+		return cookie
+	}
+
+	csrf := &http.Cookie{Name: "moviedb_csrf", Value: "login-token"}
+	response := request(t, "POST", "/login", url.Values{"name": {name}, "password": {name + "pw"}, "csrf_token": {csrf.Value}}, csrf)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	for _, cookie := range response.Result().Cookies() {
+		if cookie.Name == "moviedb_session" {
+			sessionCookies[name] = cookie
+			return cookie
+		}
+	}
+	t.Fatalf("%s could not log in", name)
+	return nil
+}
+
+// request sends a request with cookies, forms are posted.
+func request(t *testing.T, method, path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	body := strings.NewReader("")
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, "http://localhost:3008"+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	response := httptest.NewRecorder()
+	m.ServeHTTP(response, req)
+	return response
+}
+
+// adminRequest sends a request as the admin user.
+func adminRequest(t *testing.T, method, path string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	return request(t, method, path, form, append(cookies, login(t, "admin"))...)
+}
+
+// csrfCookie returns the CSRF cookie set by a form page.
+func csrfCookie(t *testing.T, response *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range response.Result().Cookies() {
+		if cookie.Name == "moviedb_csrf" {
+			return cookie
+		}
+	}
+	t.Fatal("no CSRF cookie set")
+	return nil
+}
+
+func Test_Main_AdminRequiresAuth(t *testing.T) {
+	for _, path := range []string{"/admin", "/admin/movie/new", "/admin/movie/1026", "/admin/movie/1026/delete"} {
+		response := request(t, "GET", path, nil)
+		assert.Equal(t, http.StatusSeeOther, response.Code, path)
+		assert.Equal(t, "/login?next="+url.QueryEscape(path), response.Header().Get("Location"))
+
+		response = request(t, "POST", path, url.Values{})
+		assert.Equal(t, http.StatusUnauthorized, response.Code, path)
+
+		response = request(t, "GET", path, nil, login(t, "viewer"))
+		assert.Equal(t, http.StatusForbidden, response.Code, path)
+		assert.Contains(t, response.Body.String(), "You are not allowed to do this.")
+	}
+
+	response := adminRequest(t, "GET", "/admin", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "no-store", response.Header().Get("Cache-Control"))
+	body := response.Body.String()
+	assert.Contains(t, body, `<a class="btn btn-primary" href="/admin/movie/new">`)
+	assert.Contains(t, body, `<a class="no-underline" href="/movie/1026">Army of Darkness</a>`)
+	assert.Contains(t, body, `<a class="btn btn-danger btn-xs" href="/admin/movie/1026/delete">Delete</a>`)
+}
+
+func Test_Main_AdminRoles(t *testing.T) {
+	editor := login(t, "editor")
+	response := request(t, "GET", "/admin/movie/1026", nil, editor)
+	assert.Equal(t, http.StatusOK, response.Code)
+	cookie := csrfCookie(t, response)
+
+	// editors may change movies, but only admins may delete them
+	response = request(t, "GET", "/admin/movie/1026/delete", nil, editor, cookie)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	response = request(t, "POST", "/admin/movie/1026/delete", url.Values{"csrf_token": {cookie.Value}}, editor, cookie)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+
+	response = request(t, "GET", "/admin/movie/1026", nil, editor)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func Test_Main_AdminCSRF(t *testing.T) {
+	form := adminRequest(t, "GET", "/admin/movie/1026/delete", nil)
+	cookie := csrfCookie(t, form)
+
+	// neither without a token, nor with a foreign one
+	response := adminRequest(t, "POST", "/admin/movie/1026/delete", url.Values{})
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "invalid or missing CSRF token")
+
+	response = adminRequest(t, "POST", "/admin/movie/1026/delete", url.Values{"csrf_token": {"forged"}}, cookie)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+
+	// the movie is still there
+	response = adminRequest(t, "GET", "/admin/movie/1026", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `value="Army of Darkness"`)
+}
+
+func Test_Main_AdminFlash(t *testing.T) {
+	response := adminRequest(t, "GET", "/admin?saved=1026", nil)
+	assert.Contains(t, response.Body.String(), "Movie #1026 has been saved.")
+
+	// made up messages are not shown
+	for _, query := range []string{"saved=anything", "deleted=%3Cb%3E1%3C%2Fb%3E", "added=-1"} {
+		response = adminRequest(t, "GET", "/admin?"+query, nil)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.NotContains(t, response.Body.String(), "alert-success", query)
+	}
+}
+
+func Test_Main_AdminMovie(t *testing.T) {
+	_, done := useBackend()
+	defer done()
+
+	// add a movie
+	response := adminRequest(t, "GET", "/admin/movie/new", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "<h3>New Movie</h3>")
+	assert.Contains(t, response.Body.String(), "<option>Japanisch</option>")
+	cookie := csrfCookie(t, response)
+
+	form := url.Values{
+		"csrf_token":    {cookie.Value},
+		"title":         {"Evil Dead II"},
+		"year":          {"1987"},
+		"format":        {"16:9"},
+		"length":        {"84"},
+		"region":        {"B"},
+		"rating":        {"18"},
+		"disks":         {"1"},
+		"score":         {"5"},
+		"type":          {"BluRay"},
+		"language":      {"Englisch"},
+		"genre":         {"Horror", "Comedy"},
+		"actor":         {"Bruce Campbell"},
+		"new_actors":    {"Sarah Berry"},
+		"new_directors": {"Sam Raimi"},
+	}
+	response = adminRequest(t, "POST", "/admin/movie/new", form, cookie)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	location := response.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(location, "/admin?added="))
+	id := strings.TrimPrefix(location, "/admin?added=")
+
+	response = adminRequest(t, "GET", location, nil)
+	assert.Contains(t, response.Body.String(), "Movie #"+id+" has been added.")
+
+	// it is on the frontend right away
+	response = httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "http://localhost:3008/movie/"+id+"-evil-dead-ii", nil)
+	if err != nil {
+		t.Error(err)
+	}
+	req.RequestURI = "/movie/" + id + "-evil-dead-ii"
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "Evil Dead II")
+	assert.Contains(t, response.Body.String(), "Sarah Berry")
+
+	// edit it, invalid values are shown again along with their errors
+	response = adminRequest(t, "GET", "/admin/movie/"+id, nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "<h3>Edit Movie #"+id+"</h3>")
+	assert.Contains(t, response.Body.String(), "<option selected>Sarah Berry</option>")
+
+	form.Set("year", "1887")
+	form.Set("alttitle", "Dead by Dawn")
+	response = adminRequest(t, "POST", "/admin/movie/"+id, form, cookie)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+	assert.Contains(t, response.Body.String(), `<span class="help-block">must be a number from 1888 to`)
+	assert.Contains(t, response.Body.String(), `value="Dead by Dawn"`)
+
+	form.Set("year", "1987")
+	response = adminRequest(t, "POST", "/admin/movie/"+id, form, cookie)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "/admin?saved="+id, response.Header().Get("Location"))
+
+	response = adminRequest(t, "GET", "/admin/movie/"+id, nil)
+	assert.Contains(t, response.Body.String(), `value="Dead by Dawn"`)
+
+	// and delete it again
+	response = adminRequest(t, "GET", "/admin/movie/"+id+"/delete", nil, cookie)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "Do you really want to delete <strong>Evil Dead II</strong> (1987)?")
+	assert.Contains(t, response.Body.String(), `<input type="hidden" name="csrf_token" value="`+cookie.Value+`">`)
+
+	response = adminRequest(t, "POST", "/admin/movie/"+id+"/delete", url.Values{"csrf_token": {cookie.Value}}, cookie)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "/admin?deleted="+id, response.Header().Get("Location"))
+
+	response = adminRequest(t, "GET", "/admin/movie/"+id+"/delete", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func Test_Main_Login(t *testing.T) {
+	response := request(t, "GET", "/login?next=/statistics", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `<input type="hidden" name="next" value="/statistics">`)
+	csrf := csrfCookie(t, response)
+	assert.Contains(t, response.Body.String(), `<input type="hidden" name="csrf_token" value="`+csrf.Value+`">`)
+
+	response = request(t, "POST", "/login", url.Values{"name": {"editor"}, "password": {"wrong"}, "next": {"/statistics"}, "csrf_token": {csrf.Value}}, csrf)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid user name or password.")
+	assert.Contains(t, response.Body.String(), `value="editor"`)
+
+	response = request(t, "POST", "/login", url.Values{"name": {"editor"}, "password": {"editorpw"}, "next": {"/statistics"}, "csrf_token": {csrf.Value}}, csrf)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "/statistics", response.Header().Get("Location"))
+
+	// logins never redirect to other sites
+	response = request(t, "POST", "/login", url.Values{"name": {"editor"}, "password": {"editorpw"}, "next": {"//evil.example.com"}, "csrf_token": {csrf.Value}}, csrf)
+	assert.Equal(t, "/", response.Header().Get("Location"))
+
+	// nor are they sent by other sites, which don't know the CSRF token
+	response = request(t, "POST", "/login", url.Values{"name": {"editor"}, "password": {"editorpw"}})
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Contains(t, response.Body.String(), "The login form has expired, please try again.")
+	for _, cookie := range response.Result().Cookies() {
+		assert.NotEqual(t, "moviedb_session", cookie.Name)
+	}
+
+	// the navigation shows who is logged in
+	response = request(t, "GET", "/statistics", nil)
+	assert.Contains(t, response.Body.String(), `<a href="/login?next=%2Fstatistics">`)
+	response = request(t, "GET", "/statistics", nil, login(t, "editor"))
+	assert.Contains(t, response.Body.String(), `<span class="nav-name">editor </span>`)
+	assert.Contains(t, response.Body.String(), `<a href="/admin">Admin</a>`)
+	response = request(t, "GET", "/statistics", nil, login(t, "viewer"))
+	assert.NotContains(t, response.Body.String(), `<a href="/admin">Admin</a>`)
+
+	// a tampered session is no session
+	session := *login(t, "viewer")
+	session.Value = strings.Replace(session.Value, ".", "x.", 1)
+	response = request(t, "GET", "/admin", nil, &session)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+}
+
+func Test_Main_Logout(t *testing.T) {
+	response := request(t, "GET", "/statistics", nil, login(t, "viewer"))
+	csrf := csrfCookie(t, response)
+	assert.Contains(t, response.Body.String(), `<form method="post" action="/logout"><input type="hidden" name="csrf_token" value="`+csrf.Value+`">`)
+
+	// links and forms of other sites can't log anybody out
+	response = request(t, "GET", "/logout", nil, login(t, "viewer"), csrf)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Empty(t, response.Result().Cookies())
+	response = request(t, "POST", "/logout", url.Values{}, login(t, "viewer"), csrf)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+	assert.Empty(t, response.Result().Cookies())
+
+	response = request(t, "POST", "/logout", url.Values{"csrf_token": {csrf.Value}}, login(t, "viewer"), csrf)
+	assert.Equal(t, http.StatusSeeOther, response.Code)
+	assert.Equal(t, "moviedb_session", response.Result().Cookies()[0].Name)
+	assert.Equal(t, "", response.Result().Cookies()[0].Value)
+}
+
+func Test_Main_PrivateMode(t *testing.T) {
+	authentication.Private = true
+	defer func() {
+		authentication.Private = false
+	}()
+
+	for _, path := range []string{"/", "/movies", "/movie/1026-army-of-darkness", "/feed.atom", "/movies.csv", "/sitemap.xml",
+		"/images/movies/army_of_darkness.jpg", "/covers/thumb/army_of_darkness.jpg"} {
+		response := request(t, "GET", path, nil)
+		assert.Equal(t, http.StatusSeeOther, response.Code, path)
+		assert.True(t, strings.HasPrefix(response.Header().Get("Location"), "/login?next="), path)
+	}
+	for _, path := range []string{"/login", "/healthz", "/robots.txt"} {
+		response := request(t, "GET", path, nil)
+		assert.Equal(t, http.StatusOK, response.Code, path)
+	}
+	// the layout's assets don't need a login, there are none in the tests though
+	response := request(t, "GET", "/css/jcio.css", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = request(t, "GET", "/movies", nil, login(t, "viewer"))
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func Test_Main_Covers(t *testing.T) {
+	response := request(t, "GET", "/covers/thumb/army_of_darkness.jpg", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "image/jpeg", response.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", response.Header().Get("Cache-Control"))
+	img, err := jpeg.Decode(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 92, 138), img.Bounds())
+
+	etag := response.Header().Get("ETag")
+	req, _ := http.NewRequest("GET", "http://localhost:3008/covers/thumb/army_of_darkness.jpg", nil)
+	req.Header.Set("If-None-Match", etag)
+	response = httptest.NewRecorder()
+	m.ServeHTTP(response, req)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+
+	response = request(t, "GET", "/images/movies/army_of_darkness.jpg", nil)
+	img, err = jpeg.Decode(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 300, 450), img.Bounds())
+
+	// missing covers get a placeholder
+	response = request(t, "GET", "/covers/card/kill_bill_vol1.jpg", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "public, max-age=300", response.Header().Get("Cache-Control"))
+	img, err = jpeg.Decode(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 185, 278), img.Bounds())
+
+	response = request(t, "GET", "/covers/huge/army_of_darkness.jpg", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func Test_Main_GridView(t *testing.T) {
+	response := request(t, "GET", "/movies?query=year&value=1992&view=grid", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	body := response.Body.String()
+	assert.Contains(t, body, `<a class="thumbnail no-underline" href="/movie/1026-army-of-darkness">`)
+	assert.Contains(t, body, `<img src="/covers/card/army_of_darkness.jpg" alt="Army of Darkness" width="185" height="278" class="img-responsive" loading="lazy">`)
+	assert.Contains(t, body, `<strong>Army of Darkness</strong><br>`)
+	assert.Contains(t, body, `<a class="btn btn-default active" href="/movies?query=year&amp;value=1992&amp;view=grid" title="Grid">`)
+	assert.NotContains(t, body, `<td><a class="no-underline" href="/movie/1026-army-of-darkness">Army of Darkness</a></td>`)
+	// the view is no filter, the export links still work
+	assert.Contains(t, body, `href="/feed.atom?query=year&amp;value=1992"`)
+
+	var view *http.Cookie
+	for _, cookie := range response.Result().Cookies() {
+		if cookie.Name == "moviedb_view" {
+			view = cookie
+		}
+	}
+	if !assert.NotNil(t, view) {
+		return
+	}
+	assert.Equal(t, "grid", view.Value)
+
+	// the cookie keeps the grid view on the other listings
+	response = request(t, "GET", "/", nil, view)
+	assert.Contains(t, response.Body.String(), `<img src="/covers/card/army_of_darkness.jpg"`)
+
+	response = request(t, "GET", "/person/211-quentin-tarantino", nil, view)
+	assert.Equal(t, http.StatusOK, response.Code)
+	body = response.Body.String()
+	assert.Contains(t, body, `<img src="/covers/card/from_dusk_till_dawn.jpg" alt="From Dusk Till Dawn"`)
+	assert.Contains(t, body, `<img src="/covers/card/kill_bill_vol1.jpg" alt="Kill Bill Vol.1"`)
+
+	response = request(t, "GET", "/movies?view=table", nil, view)
+	body = response.Body.String()
+	assert.Contains(t, body, `<td><a class="no-underline" href="/movie/1026-army-of-darkness">Army of Darkness</a></td>`)
+	assert.NotContains(t, body, `class="thumbnail`)
 }
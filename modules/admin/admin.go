@@ -0,0 +1,298 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/auth"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+const title = "jamesclonk.io - Movie Database - Admin"
+
+const (
+	// Paths matches all pages of the admin section.
+	Paths = `^/admin(/|$)`
+	// DeletePaths matches the pages deleting a movie.
+	DeletePaths = `^/admin/movie/[0-9]+/delete$`
+)
+
+// Admin is the /admin section, to add, edit and delete movies.
+type Admin struct {
+	source    datasource.Source
+	render    func(web.Handler) http.HandlerFunc
+	errorPage func(*http.Request, error) *web.Page
+
+	// OnChange is called with the id of a movie after it has been added, saved or deleted.
+	OnChange func(id int)
+}
+
+// New returns the admin section of the movies of source. Pages are rendered by render,
+// failed requests by errorPage.
+func New(source datasource.Source, render func(web.Handler) http.HandlerFunc, errorPage func(*http.Request, error) *web.Page) *Admin {
+	return &Admin{
+		source:    source,
+		render:    render,
+		errorPage: errorPage,
+		OnChange:  func(int) {},
+	}
+}
+
+// Routes registers all pages of the admin section on router. They have to be protected
+// by the auth middleware, see Paths.
+func (a *Admin) Routes(router *mux.Router) {
+	routes := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/admin", a.render(a.list)},
+		{"/admin/movie/new", a.edit},
+		{"/admin/movie/{id:[0-9]+}", a.edit},
+		{"/admin/movie/{id:[0-9]+}/delete", a.delete},
+	}
+	for _, route := range routes {
+		handler := route.handler
+		router.HandleFunc(route.path, func(w http.ResponseWriter, req *http.Request) {
+			// forms carry CSRF tokens and unsaved data, none of it may be cached
+			w.Header().Set("Cache-Control", "no-store")
+			handler(w, req)
+		}).Name(route.path)
+	}
+}
+
+// List is the content of the admin overview.
+type List struct {
+	Movies []moviedb.MovieListing
+	Flash  string
+}
+
+// Edit is the content of the movie form.
+type Edit struct {
+	*Form
+	Heading   string
+	Languages []Option
+	Genres    []Option
+	Actors    []Option
+	Directors []Option
+	Ratings   []Option
+	CSRF      string
+}
+
+// Delete is the content of the delete confirmation.
+type Delete struct {
+	Movie *moviedb.Movie
+	CSRF  string
+}
+
+func (a *Admin) list(w http.ResponseWriter, req *http.Request) *web.Page {
+	movies, err := a.source.Movies(req.Context(), api.Movies().OrderBy(api.Title, api.Asc))
+	if err != nil {
+		return a.errorPage(req, err)
+	}
+
+	list := List{Movies: movies}
+	// the redirects after a change tell which movie it was, anything else is ignored
+	query := req.URL.Query()
+	for _, change := range []string{"added", "saved", "deleted"} {
+		if id, err := api.ParseID(query.Get(change)); err == nil {
+			list.Flash = fmt.Sprintf("Movie #%d has been %s.", id, change)
+			break
+		}
+	}
+	return &web.Page{
+		Title:    title,
+		Content:  list,
+		Template: "admin",
+	}
+}
+
+// edit shows the form of a new or existing movie, and adds or saves it on submit.
+// Invalid forms are shown again, valid ones redirect back to the overview.
+func (a *Admin) edit(w http.ResponseWriter, req *http.Request) {
+	var id int
+	if _, ok := mux.Vars(req)["id"]; ok {
+		var err error
+		if id, err = a.id(req); err != nil {
+			a.fail(w, req, err)
+			return
+		}
+	}
+
+	if req.Method != http.MethodPost {
+		a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+			languages, genres, err := a.known(req)
+			if err != nil {
+				return a.errorPage(req, err)
+			}
+			form := &Form{Rating: "0", Disks: "1", Score: "0", Errors: make(map[string]string)}
+			if id > 0 {
+				movie, err := a.source.Movie(req.Context(), id)
+				if err != nil {
+					return a.errorPage(req, err)
+				}
+				form = FormFromMovie(movie)
+			}
+			return a.editPage(w, req, form, languages, genres)
+		})(w, req)
+		return
+	}
+
+	if err := auth.CheckCSRF(req); err != nil {
+		a.render(forbidden(err))(w, req)
+		return
+	}
+	languages, genres, err := a.known(req)
+	if err != nil {
+		a.fail(w, req, err)
+		return
+	}
+	form := ParseForm(req)
+	form.Id = id
+	if !form.Validate(languages, genres) {
+		a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+			return a.editPage(w, req, form, languages, genres)
+		})(w, req)
+		return
+	}
+
+	flash, err := a.save(req, form)
+	if err != nil {
+		a.fail(w, req, err)
+		return
+	}
+	a.OnChange(form.Id)
+	http.Redirect(w, req, fmt.Sprintf("/admin?%s=%d", flash, form.Id), http.StatusSeeOther)
+}
+
+// save adds or saves the movie of a valid form, new movies get their Id from the data source.
+func (a *Admin) save(req *http.Request, form *Form) (string, error) {
+	movie := form.Movie()
+	if form.Id > 0 {
+		return "saved", a.source.SaveMovie(req.Context(), movie)
+	}
+
+	if err := a.source.AddMovie(req.Context(), movie); err != nil {
+		return "", err
+	}
+	form.Id = movie.Id
+	return "added", nil
+}
+
+func (a *Admin) editPage(w http.ResponseWriter, req *http.Request, form *Form, languages, genres []string) *web.Page {
+	actors, err := a.source.Actors(req.Context(), api.Page{})
+	if err != nil {
+		return a.errorPage(req, err)
+	}
+	directors, err := a.source.Directors(req.Context(), api.Page{})
+	if err != nil {
+		return a.errorPage(req, err)
+	}
+
+	heading := "New Movie"
+	if form.Id > 0 {
+		heading = fmt.Sprintf("Edit Movie #%d", form.Id)
+	}
+	status := http.StatusOK
+	if len(form.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	return &web.Page{
+		Title: fmt.Sprintf("%s - %s", title, heading),
+		Content: Edit{
+			Form:      form,
+			Heading:   heading,
+			Languages: options(languages, form.Languages),
+			Genres:    options(genres, form.Genres),
+			Actors:    options(personNames(actors), form.Actors),
+			Directors: options(personNames(directors), form.Directors),
+			Ratings:   form.RatingOptions(),
+			CSRF:      auth.CSRFToken(w, req),
+		},
+		StatusCode: status,
+		Template:   "admin_movie",
+	}
+}
+
+// delete asks for confirmation, and deletes the movie once confirmed.
+func (a *Admin) delete(w http.ResponseWriter, req *http.Request) {
+	id, err := a.id(req)
+	if err != nil {
+		a.fail(w, req, err)
+		return
+	}
+
+	if req.Method == http.MethodPost {
+		if err := auth.CheckCSRF(req); err != nil {
+			a.render(forbidden(err))(w, req)
+			return
+		}
+		if err := a.source.DeleteMovie(req.Context(), id); err != nil {
+			a.fail(w, req, err)
+			return
+		}
+		a.OnChange(id)
+		http.Redirect(w, req, fmt.Sprintf("/admin?deleted=%d", id), http.StatusSeeOther)
+		return
+	}
+
+	a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		movie, err := a.source.Movie(req.Context(), id)
+		if err != nil {
+			return a.errorPage(req, err)
+		}
+		return &web.Page{
+			Title:    fmt.Sprintf("%s - Delete Movie #%d", title, id),
+			Content:  Delete{movie, auth.CSRFToken(w, req)},
+			Template: "admin_delete",
+		}
+	})(w, req)
+}
+
+// known returns the names of all languages and genres, the form can only pick those.
+func (a *Admin) known(req *http.Request) (languages, genres []string, err error) {
+	ls, err := a.source.Languages(req.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	gs, err := a.source.Genres(req.Context())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, l := range ls {
+		languages = append(languages, l.Name)
+	}
+	for _, g := range gs {
+		genres = append(genres, g.Name)
+	}
+	return languages, genres, nil
+}
+
+func personNames(people []moviedb.Person) []string {
+	names := make([]string, 0, len(people))
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// id returns the movie id of the route, if it is a valid one.
+func (a *Admin) id(req *http.Request) (int, error) {
+	return api.ParseID(mux.Vars(req)["id"])
+}
+
+// fail renders the error page of a failed request of the data source.
+func (a *Admin) fail(w http.ResponseWriter, req *http.Request, err error) {
+	a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return a.errorPage(req, err)
+	})(w, req)
+}
+
+func forbidden(err error) web.Handler {
+	return func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return web.Error(title, http.StatusForbidden, err)
+	}
+}
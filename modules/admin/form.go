@@ -0,0 +1,267 @@
+package admin
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+)
+
+var (
+	// ratings are the age ratings of the FSK, 0 is unrated
+	ratings  = []int{0, 6, 12, 16, 18}
+	pictures = regexp.MustCompile(`^[A-Za-z0-9_.-]+\.(jpg|jpeg|png|gif)$`)
+)
+
+// Form is the movie form, as entered by the user. Invalid input is kept,
+// so the form can be shown again along with the errors.
+type Form struct {
+	Id          int
+	Title       string
+	Alttitle    string
+	Year        string
+	Description string
+	Format      string
+	Length      string
+	Region      string
+	Rating      string
+	Disks       string
+	Score       string
+	Picture     string
+	Type        string
+	Languages   []string
+	Genres      []string
+	Actors      []string
+	Directors   []string
+
+	// Errors holds the validation errors, by field name.
+	Errors map[string]string
+}
+
+// FormFromMovie returns the form of an existing movie.
+func FormFromMovie(m *moviedb.Movie) *Form {
+	f := &Form{
+		Id:          m.Id,
+		Title:       m.Title,
+		Alttitle:    m.Alttitle.String,
+		Year:        strconv.Itoa(m.Year),
+		Description: m.Description,
+		Format:      m.Format,
+		Length:      strconv.Itoa(m.Length),
+		Region:      m.Region,
+		Rating:      strconv.Itoa(m.Rating),
+		Disks:       strconv.Itoa(m.Disks),
+		Score:       strconv.Itoa(m.Score),
+		Picture:     m.Picture,
+		Type:        m.Type,
+		Errors:      make(map[string]string),
+	}
+	for _, l := range m.Languages {
+		f.Languages = append(f.Languages, l.Name)
+	}
+	for _, g := range m.Genres {
+		f.Genres = append(f.Genres, g.Name)
+	}
+	for _, p := range m.Actors {
+		f.Actors = append(f.Actors, p.Name)
+	}
+	for _, p := range m.Directors {
+		f.Directors = append(f.Directors, p.Name)
+	}
+	return f
+}
+
+// ParseForm reads the form of a submitted request. People are picked from the known ones
+// and can be added by name as well, one per line.
+func ParseForm(req *http.Request) *Form {
+	req.ParseForm()
+	value := func(name string) string {
+		return strings.TrimSpace(req.PostForm.Get(name))
+	}
+	return &Form{
+		Title:       value("title"),
+		Alttitle:    value("alttitle"),
+		Year:        value("year"),
+		Description: value("description"),
+		Format:      value("format"),
+		Length:      value("length"),
+		Region:      value("region"),
+		Rating:      value("rating"),
+		Disks:       value("disks"),
+		Score:       value("score"),
+		Picture:     value("picture"),
+		Type:        value("type"),
+		Languages:   names(req.PostForm["language"]),
+		Genres:      names(req.PostForm["genre"]),
+		Actors:      names(append(req.PostForm["actor"], strings.Split(req.PostForm.Get("new_actors"), "\n")...)),
+		Directors:   names(append(req.PostForm["director"], strings.Split(req.PostForm.Get("new_directors"), "\n")...)),
+		Errors:      make(map[string]string),
+	}
+}
+
+// names trims all values and removes empty and duplicate ones.
+func names(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		if len(value) > 0 && !seen[strings.ToLower(value)] {
+			seen[strings.ToLower(value)] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Validate checks the form, languages and genres must be one of the known ones.
+// It returns false if there are any Errors.
+func (f *Form) Validate(languages, genres []string) bool {
+	f.Errors = make(map[string]string)
+
+	f.text("title", f.Title, true, 255)
+	f.text("alttitle", f.Alttitle, false, 255)
+	f.text("description", f.Description, false, 5000)
+	f.text("format", f.Format, true, 32)
+	f.text("region", f.Region, true, 32)
+	f.text("type", f.Type, true, 32)
+	f.number("year", f.Year, 1888, time.Now().Year()+5)
+	f.number("length", f.Length, 1, 1000)
+	f.number("disks", f.Disks, 1, 100)
+	f.number("score", f.Score, 0, 5)
+	if rating, ok := f.number("rating", f.Rating, 0, 18); ok && !contains(ratings, rating) {
+		f.Errors["rating"] = fmt.Sprintf("must be one of %v", ratings)
+	}
+	if len(f.Picture) > 0 && !pictures.MatchString(f.Picture) {
+		f.Errors["picture"] = "must be an image file name, like movie.jpg"
+	}
+
+	f.known("language", f.Languages, languages)
+	f.known("genre", f.Genres, genres)
+	for field, people := range map[string][]string{"actor": f.Actors, "director": f.Directors} {
+		for _, name := range people {
+			if len(name) > 255 {
+				f.Errors[field] = "names must be at most 255 characters long"
+			}
+		}
+	}
+	return len(f.Errors) == 0
+}
+
+func (f *Form) text(field, value string, required bool, max int) {
+	switch {
+	case required && len(value) == 0:
+		f.Errors[field] = "is required"
+	case len([]rune(value)) > max:
+		f.Errors[field] = fmt.Sprintf("must be at most %d characters long", max)
+	}
+}
+
+func (f *Form) number(field, value string, min, max int) (int, bool) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		f.Errors[field] = fmt.Sprintf("must be a number from %d to %d", min, max)
+		return 0, false
+	}
+	return n, true
+}
+
+func (f *Form) known(field string, values, known []string) {
+	for _, value := range values {
+		if !containsString(known, value) {
+			f.Errors[field] = fmt.Sprintf("unknown %s %q", field, value)
+			return
+		}
+	}
+}
+
+// Movie returns the movie of a valid form.
+func (f *Form) Movie() *moviedb.Movie {
+	m := &moviedb.Movie{
+		Id:          f.Id,
+		Title:       f.Title,
+		Alttitle:    sql.NullString{String: f.Alttitle, Valid: len(f.Alttitle) > 0},
+		Description: f.Description,
+		Format:      f.Format,
+		Region:      f.Region,
+		Picture:     f.Picture,
+		Type:        f.Type,
+	}
+	m.Year, _ = strconv.Atoi(f.Year)
+	m.Length, _ = strconv.Atoi(f.Length)
+	m.Rating, _ = strconv.Atoi(f.Rating)
+	m.Disks, _ = strconv.Atoi(f.Disks)
+	m.Score, _ = strconv.Atoi(f.Score)
+	for _, name := range f.Languages {
+		m.Languages = append(m.Languages, &moviedb.Language{Name: name})
+	}
+	for _, name := range f.Genres {
+		m.Genres = append(m.Genres, &moviedb.Genre{Name: name})
+	}
+	for _, name := range f.Actors {
+		m.Actors = append(m.Actors, &moviedb.Person{Name: name})
+	}
+	for _, name := range f.Directors {
+		m.Directors = append(m.Directors, &moviedb.Person{Name: name})
+	}
+	return m
+}
+
+// Option is a choice of a picker.
+type Option struct {
+	Value    string
+	Selected bool
+}
+
+// options returns all known values, with the selected ones marked.
+// Selected values that are not known are added, so they are not lost on submit.
+func options(known, selected []string) []Option {
+	all := append([]string{}, known...)
+	for _, value := range selected {
+		if !containsString(all, value) {
+			all = append(all, value)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return strings.ToLower(all[i]) < strings.ToLower(all[j])
+	})
+
+	opts := make([]Option, 0, len(all))
+	for _, value := range all {
+		opts = append(opts, Option{value, containsString(selected, value)})
+	}
+	return opts
+}
+
+// RatingOptions returns the rating choices, with the selected one marked.
+func (f *Form) RatingOptions() []Option {
+	opts := make([]Option, 0, len(ratings))
+	for _, rating := range ratings {
+		value := strconv.Itoa(rating)
+		opts = append(opts, Option{value, value == f.Rating})
+	}
+	return opts
+}
+
+func contains(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
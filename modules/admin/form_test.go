@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	languages = []string{"Deutsch", "Englisch"}
+	genres    = []string{"Action", "Horror"}
+)
+
+func validForm() url.Values {
+	return url.Values{
+		"title":         {" Army of Darkness "},
+		"year":          {"1992"},
+		"format":        {"16:9"},
+		"length":        {"81"},
+		"region":        {"B"},
+		"rating":        {"16"},
+		"disks":         {"1"},
+		"score":         {"4"},
+		"picture":       {"army_of_darkness.jpg"},
+		"type":          {"BluRay"},
+		"language":      {"Englisch"},
+		"genre":         {"Horror", "Action"},
+		"actor":         {"Bruce Campbell"},
+		"new_actors":    {"Embeth  Davidtz\r\n\nbruce campbell\n"},
+		"new_directors": {"Sam Raimi"},
+	}
+}
+
+func parse(values url.Values) *Form {
+	req, _ := http.NewRequest("POST", "/admin/movie/new", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return ParseForm(req)
+}
+
+func Test_Admin_FormValid(t *testing.T) {
+	form := parse(validForm())
+	assert.True(t, form.Validate(languages, genres))
+	assert.Equal(t, 0, len(form.Errors))
+
+	m := form.Movie()
+	assert.Equal(t, "Army of Darkness", m.Title)
+	assert.False(t, m.Alttitle.Valid)
+	assert.Equal(t, 1992, m.Year)
+	assert.Equal(t, 16, m.Rating)
+	assert.Equal(t, 2, len(m.Genres))
+	assert.Equal(t, "Horror", m.Genres[0].Name)
+	// new names are trimmed and deduplicated against the picked ones
+	assert.Equal(t, 2, len(m.Actors))
+	assert.Equal(t, "Embeth Davidtz", m.Actors[1].Name)
+	assert.Equal(t, "Sam Raimi", m.Directors[0].Name)
+}
+
+func Test_Admin_FormInvalid(t *testing.T) {
+	values := validForm()
+	values.Set("title", "  ")
+	values.Set("year", "1800")
+	values.Set("length", "abc")
+	values.Set("rating", "14")
+	values.Set("score", "6")
+	values.Set("picture", "../../etc/passwd")
+	values.Set("format", strings.Repeat("x", 33))
+	values["genre"] = []string{"Horror", "Western"}
+
+	form := parse(values)
+	assert.False(t, form.Validate(languages, genres))
+	for _, field := range []string{"title", "year", "length", "rating", "score", "picture", "format", "genre"} {
+		_, ok := form.Errors[field]
+		assert.True(t, ok, field)
+	}
+	assert.Equal(t, 8, len(form.Errors))
+	assert.Equal(t, "is required", form.Errors["title"])
+	assert.Equal(t, `unknown genre "Western"`, form.Errors["genre"])
+}
+
+func Test_Admin_FormFromMovie(t *testing.T) {
+	m := &moviedb.Movie{
+		Id:        7,
+		Title:     "Zatôichi",
+		Alttitle:  sql.NullString{String: "The Blind Swordsman", Valid: true},
+		Year:      2003,
+		Length:    116,
+		Rating:    16,
+		Disks:     1,
+		Score:     5,
+		Languages: []*moviedb.Language{{Id: 3, Name: "Japanisch"}},
+		Actors:    []*moviedb.Person{{Id: 3, Name: "Takeshi Kitano"}},
+	}
+	form := FormFromMovie(m)
+	assert.Equal(t, 7, form.Id)
+	assert.Equal(t, "2003", form.Year)
+	assert.Equal(t, []string{"Japanisch"}, form.Languages)
+
+	saved := form.Movie()
+	assert.Equal(t, m.Alttitle, saved.Alttitle)
+	assert.Equal(t, m.Length, saved.Length)
+	assert.Equal(t, "Takeshi Kitano", saved.Actors[0].Name)
+}
+
+func Test_Admin_Options(t *testing.T) {
+	opts := options([]string{"Horror", "action"}, []string{"Horror", "Western"})
+	assert.Equal(t, []Option{{"action", false}, {"Horror", true}, {"Western", true}}, opts)
+
+	form := &Form{Rating: "12"}
+	assert.Equal(t, []Option{{"0", false}, {"6", false}, {"12", true}, {"16", false}, {"18", false}}, form.RatingOptions())
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+)
+
+// ErrInvalid is wrapped by all errors about requests the backend must never get.
+var ErrInvalid = errors.New("invalid backend request")
+
+var (
+	ids     = regexp.MustCompile(`^[1-9][0-9]{0,9}$`)
+	numbers = regexp.MustCompile(`^[0-9]{1,10}$`)
+)
+
+// Getter fetches the backend response of a URL built by a Client.
+// The request is given up once ctx is done.
+type Getter interface {
+	Get(ctx context.Context, url string) (string, error)
+}
+
+// GetterFunc adapts an ordinary function to the Getter interface.
+type GetterFunc func(ctx context.Context, url string) (string, error)
+
+func (f GetterFunc) Get(ctx context.Context, url string) (string, error) {
+	return f(ctx, url)
+}
+
+// Client builds the URLs of the moviedb-backend API below URL.
+type Client struct {
+	URL string
+}
+
+// New returns the Client of the backend at backendUrl.
+func New(backendUrl string) *Client {
+	return &Client{URL: strings.TrimSuffix(backendUrl, "/")}
+}
+
+// Movies returns the URL of listing, if it is valid.
+func (c *Client) Movies(listing *Listing) (string, error) {
+	if err := listing.Validate(); err != nil {
+		return "", err
+	}
+	return c.URL + listing.Path(), nil
+}
+
+// Movie returns the URL of the movie id.
+func (c *Client) Movie(id int) string {
+	return fmt.Sprintf("%s/movie/%d", c.URL, id)
+}
+
+// NewMovie returns the URL new movies are posted to.
+func (c *Client) NewMovie() string {
+	return c.URL + "/movie"
+}
+
+// Person returns the URL of the person id.
+func (c *Client) Person(id int) string {
+	return fmt.Sprintf("%s/person/%d", c.URL, id)
+}
+
+// Actors returns the URL of all actors, of only one page of them if page is set.
+func (c *Client) Actors(page Page) string {
+	return c.URL + page.path("/actors")
+}
+
+// Directors returns the URL of all directors, of only one page of them if page is set.
+func (c *Client) Directors(page Page) string {
+	return c.URL + page.path("/directors")
+}
+
+// Genres returns the URL of all genres.
+func (c *Client) Genres() string {
+	return c.URL + "/genres"
+}
+
+// Languages returns the URL of all languages.
+func (c *Client) Languages() string {
+	return c.URL + "/languages"
+}
+
+// Statistics returns the URL of the statistics of the whole database.
+func (c *Client) Statistics() string {
+	return c.URL + "/statistics"
+}
+
+// ParseID returns id as number, if it is a valid backend id.
+func ParseID(id string) (int, error) {
+	if !ids.MatchString(id) {
+		return 0, invalid("invalid id %q", id)
+	}
+	return strconv.Atoi(id)
+}
+
+// Page is a page of a listing, the backend returns the whole listing if Number is 0.
+type Page struct {
+	Number  int
+	PerPage int
+}
+
+// ParsePage returns the page and per_page parameters of values, invalid ones are ignored.
+func ParsePage(values url.Values) Page {
+	var page Page
+	if number, err := strconv.Atoi(values.Get("page")); err == nil && number > 0 {
+		page.Number = number
+		if perPage, err := strconv.Atoi(values.Get("per_page")); err == nil && perPage > 0 {
+			page.PerPage = perPage
+		}
+	}
+	return page
+}
+
+func (p Page) encode() string {
+	if p.Number <= 0 {
+		return ""
+	}
+	query := "page=" + strconv.Itoa(p.Number)
+	if p.PerPage > 0 {
+		query += "&per_page=" + strconv.Itoa(p.PerPage)
+	}
+	return query
+}
+
+func (p Page) path(path string) string {
+	if query := p.encode(); len(query) > 0 {
+		return path + "?" + query
+	}
+	return path
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &upstream.Error{
+		Kind: upstream.NotFound,
+		Err:  fmt.Errorf("%w: %s", ErrInvalid, fmt.Sprintf(format, args...)),
+	}
+}
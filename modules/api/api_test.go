@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, query string) (*Listing, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseListing(values)
+}
+
+func Test_Api_ParseID(t *testing.T) {
+	id, err := ParseID("1026")
+	assert.NoError(t, err)
+	assert.Equal(t, 1026, id)
+
+	for _, invalid := range []string{"", "0", "012", "-1", "1026abc", "../statistics", "12345678901"} {
+		_, err := ParseID(invalid)
+		assert.True(t, errors.Is(err, ErrInvalid), invalid)
+		assert.True(t, upstream.Is(err, upstream.NotFound), invalid)
+	}
+}
+
+func Test_Api_Client(t *testing.T) {
+	c := New("http://backend/")
+	assert.Equal(t, "http://backend/movie/1026", c.Movie(1026))
+	assert.Equal(t, "http://backend/movie", c.NewMovie())
+	assert.Equal(t, "http://backend/person/211", c.Person(211))
+	assert.Equal(t, "http://backend/actors", c.Actors(Page{}))
+	assert.Equal(t, "http://backend/directors?page=2&per_page=10", c.Directors(Page{2, 10}))
+	assert.Equal(t, "http://backend/genres", c.Genres())
+	assert.Equal(t, "http://backend/languages", c.Languages())
+	assert.Equal(t, "http://backend/statistics", c.Statistics())
+
+	movies, err := c.Movies(Movies())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://backend/movies", movies)
+
+	movies, err = c.Movies(Movies().Where(Actor, "211").OrderBy(Title, Asc))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://backend/movies?query=actor&value=211&sort=title&by=asc", movies)
+
+	_, err = c.Movies(Movies().Where(Actor, "211&query=id"))
+	assert.True(t, errors.Is(err, ErrInvalid))
+}
+
+func Test_Api_Listing(t *testing.T) {
+	l, err := parse(t, "sort=year&by=desc&value=Kill%20Bill&query=title&page=2&per_page=10&view=grid")
+	assert.NoError(t, err)
+	assert.Equal(t, []Query{{Title, "Kill Bill"}}, l.Queries)
+	assert.Equal(t, []Sort{{Year, Desc}}, l.Sorts)
+	assert.Equal(t, Page{2, 10}, l.Page)
+	assert.Equal(t, "query=title&value=Kill+Bill", l.Filter())
+	assert.Equal(t, "/movies?query=title&value=Kill+Bill&sort=year&by=desc&page=2&per_page=10", l.Path())
+
+	// values are escaped, they can't add parameters
+	l = Movies().Where(Search, "a&query=b#c")
+	assert.NoError(t, l.Validate())
+	assert.Equal(t, "query=search&value=a%26query%3Db%23c", l.Encode())
+
+	l, err = parse(t, "page=abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "/movies", l.Path())
+}
+
+func Test_Api_CanonicalListing(t *testing.T) {
+	l, err := parse(t, "query=genre&value=23&query=genre&value=9&query=genre&value=23&sort=title&by=asc&sort=year&by=desc&sort=title&by=desc&page=2")
+	assert.NoError(t, err)
+	l = l.Canonical()
+	assert.Equal(t, []Query{{Genre, "23"}, {Genre, "9"}}, l.Queries)
+	assert.Equal(t, []Sort{{Title, Asc}, {Year, Desc}}, l.Sorts)
+	assert.Equal(t, url.Values{
+		"query": {"genre", "genre"}, "value": {"23", "9"}, "sort": {"title", "year"}, "by": {"asc", "desc"},
+	}, l.Values())
+}
+
+func Test_Api_InvalidListing(t *testing.T) {
+	for _, query := range []string{
+		"query=id&value=1",
+		"query=genre&value=horror",
+		"query=year&value=-1",
+		"query=title&value=",
+		"query=title",
+		"query=title&value=a&value=b",
+		"sort=picture&by=asc",
+		"sort=title&by=up",
+		"sort=title",
+	} {
+		_, err := parse(t, query)
+		assert.True(t, errors.Is(err, ErrInvalid), query)
+		assert.True(t, upstream.Is(err, upstream.NotFound), query)
+	}
+}
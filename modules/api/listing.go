@@ -0,0 +1,200 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Query keys, as known to moviedb.NewQuery.
+const (
+	Title      = "title"
+	Year       = "year"
+	Score      = "score"
+	Rating     = "rating"
+	DiskRegion = "disk_region"
+	DiskType   = "disk_type"
+	Language   = "language"
+	Genre      = "genre"
+	Format     = "format"
+	Disks      = "disks"
+	Char       = "char"
+	Search     = "search"
+	Actor      = "actor"
+	Director   = "director"
+	Length     = "length"
+)
+
+// Sort fields besides the query keys of the same name, as known to moviedb.NewSort.
+const (
+	Id = "id"
+)
+
+const (
+	Asc  = "asc"
+	Desc = "desc"
+)
+
+// queryKeys are all query keys, those with numeric values are true.
+var queryKeys = map[string]bool{
+	Title: false, Year: true, Score: true, Rating: true, DiskRegion: false, DiskType: false,
+	Language: true, Genre: true, Format: false, Disks: true, Char: false, Search: false,
+	Actor: true, Director: true, Length: true,
+}
+
+var sortFields = map[string]bool{
+	Id: true, Title: true, Year: true, Score: true, Rating: true, Format: true,
+	DiskRegion: true, Length: true, Disks: true, DiskType: true,
+}
+
+// Query filters a listing by Key, like moviedb.Query.
+type Query struct {
+	Key   string
+	Value string
+}
+
+// Sort orders a listing by Field, like moviedb.Sort.
+type Sort struct {
+	Field string
+	Order string
+}
+
+// Listing is a movie listing of the backend, like moviedb.MovieListingOptions.
+type Listing struct {
+	Queries []Query
+	Sorts   []Sort
+	Page    Page
+}
+
+// Movies returns the listing of all movies, to be narrowed down.
+func Movies() *Listing {
+	return &Listing{}
+}
+
+// Where only lists the movies matching value by key.
+func (l *Listing) Where(key, value string) *Listing {
+	l.Queries = append(l.Queries, Query{key, value})
+	return l
+}
+
+// OrderBy sorts the movies by field, after any previous sorts.
+func (l *Listing) OrderBy(field, order string) *Listing {
+	l.Sorts = append(l.Sorts, Sort{field, order})
+	return l
+}
+
+// ParseListing returns the listing of the query, value, sort, by, page and per_page parameters of values,
+// all others are ignored. Unknown keys and fields, or unpaired parameters are an error.
+func ParseListing(values url.Values) (*Listing, error) {
+	l := Movies()
+	query, value := values["query"], values["value"]
+	if len(query) != len(value) {
+		return nil, invalid("%d query but %d value parameters", len(query), len(value))
+	}
+	for i := range query {
+		l.Where(query[i], value[i])
+	}
+	sort, by := values["sort"], values["by"]
+	if len(sort) != len(by) {
+		return nil, invalid("%d sort but %d by parameters", len(sort), len(by))
+	}
+	for i := range sort {
+		l.OrderBy(sort[i], by[i])
+	}
+	l.Page = ParsePage(values)
+
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Validate returns an error if the listing has unknown keys or fields, or invalid values.
+func (l *Listing) Validate() error {
+	for _, q := range l.Queries {
+		numeric, ok := queryKeys[q.Key]
+		switch {
+		case !ok:
+			return invalid("unknown query key %q", q.Key)
+		case numeric && !numbers.MatchString(q.Value):
+			return invalid("%s must be a number, not %q", q.Key, q.Value)
+		case len(q.Value) == 0:
+			return invalid("%s must not be empty", q.Key)
+		}
+	}
+	for _, s := range l.Sorts {
+		if !sortFields[s.Field] {
+			return invalid("unknown sort field %q", s.Field)
+		}
+		if s.Order != Asc && s.Order != Desc {
+			return invalid("unknown sort order %q", s.Order)
+		}
+	}
+	return nil
+}
+
+// Canonical returns the listing without duplicates: queries repeated with the same value,
+// and sorts by fields it is already sorted by.
+func (l *Listing) Canonical() *Listing {
+	c := &Listing{Page: l.Page}
+	queries := make(map[Query]bool)
+	for _, q := range l.Queries {
+		if !queries[q] {
+			queries[q] = true
+			c.Queries = append(c.Queries, q)
+		}
+	}
+	fields := make(map[string]bool)
+	for _, s := range l.Sorts {
+		if !fields[s.Field] {
+			fields[s.Field] = true
+			c.Sorts = append(c.Sorts, s)
+		}
+	}
+	return c
+}
+
+// Values returns the queries and sorts of the listing as query parameters, without its page.
+func (l *Listing) Values() url.Values {
+	values := url.Values{}
+	for _, q := range l.Queries {
+		values.Add("query", q.Key)
+		values.Add("value", q.Value)
+	}
+	for _, s := range l.Sorts {
+		values.Add("sort", s.Field)
+		values.Add("by", s.Order)
+	}
+	return values
+}
+
+// Filter returns only the queries of the listing, URL encoded.
+func (l *Listing) Filter() string {
+	parts := make([]string, 0, len(l.Queries))
+	for _, q := range l.Queries {
+		parts = append(parts, "query="+url.QueryEscape(q.Key)+"&value="+url.QueryEscape(q.Value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// Encode returns the listing as URL encoded query, the queries first, then sorts and the page.
+func (l *Listing) Encode() string {
+	var parts []string
+	if filter := l.Filter(); len(filter) > 0 {
+		parts = append(parts, filter)
+	}
+	for _, s := range l.Sorts {
+		parts = append(parts, "sort="+url.QueryEscape(s.Field)+"&by="+url.QueryEscape(s.Order))
+	}
+	if page := l.Page.encode(); len(page) > 0 {
+		parts = append(parts, page)
+	}
+	return strings.Join(parts, "&")
+}
+
+// Path returns the backend path of the listing, which is also the path of its page in the frontend.
+func (l *Listing) Path() string {
+	if query := l.Encode(); len(query) > 0 {
+		return "/movies?" + query
+	}
+	return "/movies"
+}
@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/stdlib/logger"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+var (
+	log *logrus.Logger
+
+	// ErrForbidden is shown to users lacking the role for a page.
+	ErrForbidden = &statusError{http.StatusForbidden, "You are not allowed to do this."}
+	// ErrUnauthorized is shown to anonymous users trying to change something.
+	ErrUnauthorized = &statusError{http.StatusUnauthorized, "Please log in first."}
+)
+
+func init() {
+	log = logger.GetLogger()
+}
+
+const title = "jamesclonk.io - Movie Database - Login"
+
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+type userKey struct{}
+
+type rule struct {
+	pattern *regexp.Regexp
+	role    Role
+}
+
+// Auth logs users in and out, and lets only those with the required role pass.
+// Reading requires no login, unless Private is set, writing needs an Editor.
+type Auth struct {
+	Users    *Store
+	Sessions *Sessions
+	// Private requires a login for every page, except for the Public ones.
+	Private bool
+	// Public are the path prefixes open to anyone, even if Private is set.
+	Public []string
+
+	rules  []rule
+	render func(web.Handler) http.HandlerFunc
+}
+
+// New returns the Auth of users, its pages are rendered by render.
+func New(users *Store, sessions *Sessions, render func(web.Handler) http.HandlerFunc) *Auth {
+	return &Auth{
+		Users:    users,
+		Sessions: sessions,
+		// logging out needs no role, only the CSRF token, like logging in
+		Public: []string{"/login", "/logout"},
+		render: render,
+	}
+}
+
+// Require lets only users with at least role access the paths matching pattern.
+func (a *Auth) Require(pattern string, role Role) {
+	a.rules = append(a.rules, rule{regexp.MustCompile(pattern), role})
+}
+
+// Required returns the least role needed for req.
+func (a *Auth) Required(req *http.Request) Role {
+	role := Anonymous
+	public := a.public(req.URL.Path)
+	if a.Private && !public {
+		role = Viewer
+	}
+	if !public && !safe(req.Method) {
+		role = Editor
+	}
+	for _, r := range a.rules {
+		if r.role > role && r.pattern.MatchString(req.URL.Path) {
+			role = r.role
+		}
+	}
+	return role
+}
+
+func (a *Auth) public(path string) bool {
+	for _, prefix := range a.Public {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ServeHTTP is the negroni middleware, it puts the logged in user into the request context.
+// Anonymous users are sent to the login page, others lacking the required role get a 403.
+func (a *Auth) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	user := a.user(req)
+	if user != nil {
+		req = req.WithContext(withUser(req, user))
+	}
+
+	required := a.Required(req)
+	switch {
+	case required == Anonymous || user.Can(required):
+		next(w, req)
+	case user == nil && safe(req.Method):
+		http.Redirect(w, req, "/login?next="+url.QueryEscape(req.URL.RequestURI()), http.StatusSeeOther)
+	case user == nil:
+		a.fail(w, req, ErrUnauthorized)
+	default:
+		log.WithFields(logrus.Fields{
+			"user":     user.Name,
+			"role":     user.Role.String(),
+			"required": required.String(),
+			"path":     req.URL.Path,
+		}).Warn("Access denied")
+		a.fail(w, req, ErrForbidden)
+	}
+}
+
+// user returns the user of the session of req, as long as the user still exists.
+func (a *Auth) user(req *http.Request) *User {
+	session, err := a.Sessions.Read(req)
+	if err != nil {
+		return nil
+	}
+	user, ok := a.Users.Get(session.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
+
+func withUser(req *http.Request, user *User) context.Context {
+	return context.WithValue(req.Context(), userKey{}, user)
+}
+
+// CurrentUser returns the logged in user of req, or nil.
+func CurrentUser(req *http.Request) *User {
+	user, _ := req.Context().Value(userKey{}).(*User)
+	return user
+}
+
+// Routes registers /login and /logout on router. Users log out with a form, like they log in,
+// so no other site can do it for them.
+func (a *Auth) Routes(router *mux.Router) {
+	router.HandleFunc("/login", a.login).Name("/login")
+	router.HandleFunc("/logout", a.logout).Methods("POST").Name("/logout")
+}
+
+// Login is the content of the login page.
+type Login struct {
+	Name  string
+	Next  string
+	Error string
+	CSRF  string
+}
+
+func (a *Auth) login(w http.ResponseWriter, req *http.Request) {
+	next := redirectTarget(req.FormValue("next"))
+	if req.Method != http.MethodPost {
+		if CurrentUser(req) != nil {
+			http.Redirect(w, req, next, http.StatusSeeOther)
+			return
+		}
+		a.loginPage(w, req, Login{Next: next, CSRF: CSRFToken(w, req)}, http.StatusOK)
+		return
+	}
+
+	name := strings.TrimSpace(req.PostFormValue("name"))
+	if err := CheckCSRF(req); err != nil {
+		a.loginPage(w, req, Login{Name: name, Next: next, Error: "The login form has expired, please try again.", CSRF: CSRFToken(w, req)}, http.StatusForbidden)
+		return
+	}
+	user, err := a.Users.Authenticate(name, req.PostFormValue("password"))
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"user":   name,
+			"remote": req.RemoteAddr,
+		}).Warn("Login failed")
+		a.loginPage(w, req, Login{Name: name, Next: next, Error: "Invalid user name or password.", CSRF: CSRFToken(w, req)}, http.StatusUnauthorized)
+		return
+	}
+
+	a.Sessions.Issue(w, req, user)
+	http.Redirect(w, req, next, http.StatusSeeOther)
+}
+
+func (a *Auth) logout(w http.ResponseWriter, req *http.Request) {
+	if err := CheckCSRF(req); err != nil {
+		a.fail(w, req, ErrCSRF)
+		return
+	}
+	a.Sessions.Clear(w, req)
+	http.Redirect(w, req, "/", http.StatusSeeOther)
+}
+
+func (a *Auth) loginPage(w http.ResponseWriter, req *http.Request, login Login, status int) {
+	a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return &web.Page{
+			Title:      title,
+			Content:    login,
+			StatusCode: status,
+			Template:   "login",
+		}
+	})(w, req)
+}
+
+func (a *Auth) fail(w http.ResponseWriter, req *http.Request, err *statusError) {
+	a.render(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return web.Error("jamesclonk.io - Movie Database - Error", err.status, err)
+	})(w, req)
+}
+
+// redirectTarget returns next if it is a path on this site, so the login can't redirect elsewhere.
+func redirectTarget(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// Navigation wraps fn to add the login, or the logged in user, to the navigation of its page.
+func Navigation(fn web.Handler) web.Handler {
+	return func(w http.ResponseWriter, req *http.Request) *web.Page {
+		page := fn(w, req)
+
+		user := CurrentUser(req)
+		element := web.NavigationElement{Name: "Login", Link: "/login", Icon: "fa-sign-in"}
+		if req.URL.Path != "/login" {
+			element.Link += "?next=" + url.QueryEscape(req.URL.RequestURI())
+		}
+		if user != nil {
+			element = web.NavigationElement{Name: user.Name, Icon: "fa-user"}
+			if user.Can(Editor) {
+				element.Dropdown = append(element.Dropdown, web.NavigationElement{Name: "Admin", Link: "/admin"})
+			}
+			// the layout renders entries with a dropdown of their own as forms, posting its elements
+			element.Dropdown = append(element.Dropdown, web.NavigationElement{
+				Name:     "Logout",
+				Link:     "/logout",
+				Dropdown: []web.NavigationElement{{Name: CSRFField, Link: CSRFToken(w, req)}},
+			})
+		}
+
+		// the navigation is shared by all pages, it must not be appended to in place
+		nav := make(web.Navigation, 0, len(page.Navigation)+1)
+		page.Navigation = append(append(nav, page.Navigation...), element)
+		return page
+	}
+}
@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/stdlib/web"
+	"github.com/stretchr/testify/assert"
+)
+
+var users = func() *Store {
+	s := NewStore()
+	for name, role := range map[string]Role{"viewer": Viewer, "editor": Editor, "admin": Admin} {
+		if err := s.Put(name, name+"pw", role); err != nil {
+			panic(err)
+		}
+	}
+	return s
+}()
+
+// render writes the status code and error or template of a page.
+func render(fn web.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		page := fn(w, req)
+		if page.StatusCode == 0 {
+			page.StatusCode = http.StatusOK
+		}
+		w.WriteHeader(page.StatusCode)
+		if page.Error != nil {
+			w.Write([]byte(page.Error.Error()))
+			return
+		}
+		w.Write([]byte(page.Template))
+	}
+}
+
+func newAuth() *Auth {
+	a := New(users, NewSessions([]byte("secret"), time.Hour), render)
+	a.Require(`^/admin(/|$)`, Editor)
+	a.Require(`^/admin/.*/delete$`, Admin)
+	return a
+}
+
+func serve(a *Auth, method, path, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "http://localhost"+path, nil)
+	if len(user) > 0 {
+		u, _ := users.Get(user)
+		rec := httptest.NewRecorder()
+		a.Sessions.Issue(rec, req, u)
+		req.AddCookie(rec.Result().Cookies()[0])
+	}
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req, func(w http.ResponseWriter, req *http.Request) {
+		if user := CurrentUser(req); user != nil {
+			w.Write([]byte(user.Name))
+		}
+	})
+	return rec
+}
+
+func Test_Auth_Required(t *testing.T) {
+	a := newAuth()
+	required := func(method, path string) Role {
+		req, _ := http.NewRequest(method, path, nil)
+		return a.Required(req)
+	}
+
+	assert.Equal(t, Anonymous, required("GET", "/movies"))
+	assert.Equal(t, Editor, required("POST", "/movies"))
+	assert.Equal(t, Anonymous, required("POST", "/login"))
+	assert.Equal(t, Editor, required("GET", "/admin"))
+	assert.Equal(t, Editor, required("GET", "/admin/movie/1"))
+	assert.Equal(t, Anonymous, required("GET", "/administrator"))
+	assert.Equal(t, Admin, required("GET", "/admin/movie/1/delete"))
+
+	a.Private = true
+	assert.Equal(t, Viewer, required("GET", "/movies"))
+	assert.Equal(t, Anonymous, required("GET", "/login"))
+	assert.Equal(t, Editor, required("GET", "/admin"))
+}
+
+func Test_Auth_Middleware(t *testing.T) {
+	a := newAuth()
+
+	rec := serve(a, "GET", "/movies", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(a, "GET", "/movies", "viewer")
+	assert.Equal(t, "viewer", rec.Body.String())
+
+	rec = serve(a, "GET", "/admin/movie/1?x=y", "")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/login?next=%2Fadmin%2Fmovie%2F1%3Fx%3Dy", rec.Header().Get("Location"))
+
+	rec = serve(a, "POST", "/admin/movie/1", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serve(a, "POST", "/admin/movie/1", "viewer")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serve(a, "POST", "/admin/movie/1", "editor")
+	assert.Equal(t, "editor", rec.Body.String())
+	rec = serve(a, "POST", "/admin/movie/1/delete", "editor")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serve(a, "POST", "/admin/movie/1/delete", "admin")
+	assert.Equal(t, "admin", rec.Body.String())
+
+	a.Private = true
+	rec = serve(a, "GET", "/movies", "")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	rec = serve(a, "GET", "/login", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func Test_Auth_DeletedUser(t *testing.T) {
+	a := newAuth()
+	a.Users = NewStore()
+	rec := serve(a, "GET", "/admin", "admin")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+}
+
+func Test_Auth_Login(t *testing.T) {
+	a := newAuth()
+	csrf := &http.Cookie{Name: csrfCookie, Value: "secret-token"}
+	login := func(values url.Values, origin string) *httptest.ResponseRecorder {
+		values.Set(CSRFField, csrf.Value)
+		req, _ := http.NewRequest("POST", "http://localhost/login", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(csrf)
+		if len(origin) > 0 {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		a.login(rec, req)
+		return rec
+	}
+
+	rec := login(url.Values{"name": {"editor"}, "password": {"editorpw"}, "next": {"/admin"}}, "")
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/admin", rec.Header().Get("Location"))
+	assert.Equal(t, sessionCookie, rec.Result().Cookies()[0].Name)
+
+	rec = login(url.Values{"name": {"editor"}, "password": {"wrong"}}, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "login", rec.Body.String())
+	assert.Equal(t, 0, len(rec.Result().Cookies()))
+
+	rec = login(url.Values{"name": {"editor"}, "password": {"editorpw"}}, "https://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// without the CSRF token
+	req, _ := http.NewRequest("POST", "http://localhost/login", strings.NewReader("name=editor&password=editorpw"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	a.login(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	for _, cookie := range rec.Result().Cookies() {
+		assert.NotEqual(t, sessionCookie, cookie.Name)
+	}
+}
+
+func Test_Auth_Logout(t *testing.T) {
+	a := newAuth()
+	csrf := &http.Cookie{Name: csrfCookie, Value: "secret-token"}
+	logout := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "http://localhost/logout", strings.NewReader(url.Values{CSRFField: {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(csrf)
+		rec := httptest.NewRecorder()
+		a.logout(rec, req)
+		return rec
+	}
+
+	rec := logout("forged")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, 0, len(rec.Result().Cookies()))
+
+	rec = logout(csrf.Value)
+	assert.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, sessionCookie, rec.Result().Cookies()[0].Name)
+}
+
+func Test_Auth_RedirectTarget(t *testing.T) {
+	assert.Equal(t, "/movies?query=year&value=1999", redirectTarget("/movies?query=year&value=1999"))
+	for _, next := range []string{"", "movies", "//evil.example.com", "/\\evil.example.com", "https://evil.example.com"} {
+		assert.Equal(t, "/", redirectTarget(next), next)
+	}
+}
+
+func Test_Auth_Navigation(t *testing.T) {
+	shared := web.Navigation{{Name: "Movies", Link: "/movies"}}
+	handler := Navigation(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return &web.Page{Navigation: shared}
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost/movies", nil)
+	page := handler(httptest.NewRecorder(), req)
+	assert.Equal(t, 2, len(page.Navigation))
+	assert.Equal(t, "/login?next=%2Fmovies", page.Navigation[1].Link)
+	assert.Equal(t, 1, len(shared))
+
+	viewer, _ := users.Get("viewer")
+	page = handler(httptest.NewRecorder(), req.WithContext(withUser(req, viewer)))
+	assert.Equal(t, "viewer", page.Navigation[1].Name)
+	logout := page.Navigation[1].Dropdown[0]
+	assert.Equal(t, "/logout", logout.Link)
+	assert.Equal(t, CSRFField, logout.Dropdown[0].Name)
+	assert.Equal(t, 43, len(logout.Dropdown[0].Link))
+
+	admin, _ := users.Get("admin")
+	page = handler(httptest.NewRecorder(), req.WithContext(withUser(req, admin)))
+	assert.Equal(t, "/admin", page.Navigation[1].Dropdown[0].Link)
+}
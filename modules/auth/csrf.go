@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+)
+
+const (
+	csrfCookie = "moviedb_csrf"
+	// CSRFField is the name of the hidden form field carrying the CSRF token.
+	CSRFField = "csrf_token"
+)
+
+// ErrCSRF is returned for state changing requests without a valid CSRF token.
+var ErrCSRF = &statusError{http.StatusForbidden, "invalid or missing CSRF token, please reload the form and try again"}
+
+// CSRFToken returns the CSRF token of the browser, setting a new one if it has none yet.
+// Forms have to send it back, which a foreign site can't do, as it can't read the cookie.
+func CSRFToken(w http.ResponseWriter, req *http.Request) string {
+	if cookie, err := req.Cookie(csrfCookie); err == nil && len(cookie.Value) > 0 {
+		return cookie.Value
+	}
+
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   Secure(req),
+		SameSite: http.SameSiteStrictMode,
+	})
+	return token
+}
+
+// CheckCSRF verifies that a form submission carries the CSRF token of the browser,
+// and that it was sent from this site, if the browser tells.
+func CheckCSRF(req *http.Request) error {
+	cookie, err := req.Cookie(csrfCookie)
+	if err != nil || len(cookie.Value) == 0 {
+		return ErrCSRF
+	}
+	token := req.PostFormValue(CSRFField)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		return ErrCSRF
+	}
+
+	if !sameOrigin(req) {
+		return ErrCSRF
+	}
+	return nil
+}
+
+// sameOrigin returns false if the browser says the request was sent from another site.
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if len(origin) == 0 || origin == "null" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == req.Host
+}
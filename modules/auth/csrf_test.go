@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func post(token string, cookie *http.Cookie) *http.Request {
+	req, _ := http.NewRequest("POST", "http://moviedb.example.com/admin/movie/1/delete", strings.NewReader(url.Values{CSRFField: {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func Test_Auth_CSRFToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://moviedb.example.com/admin/movie/new", nil)
+	token := CSRFToken(rec, req)
+	assert.Equal(t, 43, len(token))
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, csrfCookie, cookies[0].Name)
+	assert.Equal(t, token, cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.False(t, cookies[0].Secure)
+
+	// an existing token is kept
+	rec = httptest.NewRecorder()
+	req.AddCookie(cookies[0])
+	assert.Equal(t, token, CSRFToken(rec, req))
+	assert.Equal(t, 0, len(rec.Result().Cookies()))
+
+	// behind a TLS terminating proxy
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "http://moviedb.example.com/admin/movie/new", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	CSRFToken(rec, req)
+	assert.True(t, rec.Result().Cookies()[0].Secure)
+}
+
+func Test_Auth_CheckCSRF(t *testing.T) {
+	cookie := &http.Cookie{Name: csrfCookie, Value: "secret-token"}
+
+	assert.NoError(t, CheckCSRF(post("secret-token", cookie)))
+	assert.Equal(t, ErrCSRF, CheckCSRF(post("secret-token", nil)))
+	assert.Equal(t, ErrCSRF, CheckCSRF(post("", cookie)))
+	assert.Equal(t, ErrCSRF, CheckCSRF(post("other-token", cookie)))
+
+	req := post("secret-token", cookie)
+	req.Header.Set("Origin", "http://moviedb.example.com")
+	assert.NoError(t, CheckCSRF(req))
+
+	req = post("secret-token", cookie)
+	req.Header.Set("Origin", "https://evil.example.com")
+	assert.Equal(t, ErrCSRF, CheckCSRF(req))
+}
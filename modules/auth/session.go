@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const sessionCookie = "moviedb_session"
+
+// SecureCookies marks all cookies Secure, even for requests that don't look like HTTPS.
+var SecureCookies = false
+
+// ErrSession is returned for missing, tampered and expired session cookies.
+var ErrSession = errors.New("no valid session")
+
+// Session is the login of a user, kept in a signed cookie.
+type Session struct {
+	User    string `json:"u"`
+	Expires int64  `json:"e"`
+}
+
+// Sessions issues and verifies session cookies, signed with HMAC-SHA256.
+type Sessions struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSessions returns Sessions lasting ttl. Without a secret a random one is used,
+// so sessions won't survive a restart.
+func NewSessions(secret []byte, ttl time.Duration) *Sessions {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	return &Sessions{secret: secret, ttl: ttl}
+}
+
+// Issue sets the session cookie of user.
+func (s *Sessions) Issue(w http.ResponseWriter, req *http.Request, user *User) {
+	expires := time.Now().Add(s.ttl)
+	payload, _ := json.Marshal(Session{User: user.Name, Expires: expires.Unix()})
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	s.setCookie(w, req, value+"."+s.sign(value), expires)
+}
+
+// Clear removes the session cookie.
+func (s *Sessions) Clear(w http.ResponseWriter, req *http.Request) {
+	s.setCookie(w, req, "", time.Unix(0, 0))
+}
+
+// Read returns the session of req, if it is signed by us and has not expired.
+func (s *Sessions) Read(req *http.Request) (*Session, error) {
+	cookie, err := req.Cookie(sessionCookie)
+	if err != nil {
+		return nil, ErrSession
+	}
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return nil, ErrSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrSession
+	}
+
+	var session Session
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, ErrSession
+	}
+	if time.Now().Unix() >= session.Expires {
+		return nil, ErrSession
+	}
+	return &session, nil
+}
+
+func (s *Sessions) sign(value string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Secure reports whether cookies set in the response to req should be Secure. That is if
+// SecureCookies is set, or req came in over HTTPS, directly or through a TLS terminating proxy.
+func Secure(req *http.Request) bool {
+	return SecureCookies || req.TLS != nil || strings.EqualFold(req.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func (s *Sessions) setCookie(w http.ResponseWriter, req *http.Request, value string, expires time.Time) {
+	cookie := &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   Secure(req),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if len(value) == 0 {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
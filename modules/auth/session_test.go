@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func issue(s *Sessions, user *User) *http.Cookie {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	s.Issue(rec, req, user)
+	return rec.Result().Cookies()[0]
+}
+
+func read(s *Sessions, cookie *http.Cookie) (*Session, error) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	return s.Read(req)
+}
+
+func Test_Auth_Session(t *testing.T) {
+	s := NewSessions([]byte("secret"), time.Hour)
+	cookie := issue(s, &User{Name: "jc"})
+	assert.Equal(t, sessionCookie, cookie.Name)
+	assert.True(t, cookie.HttpOnly)
+
+	session, err := read(s, cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, "jc", session.User)
+
+	// a session signed with another secret, like before a restart without one
+	_, err = read(NewSessions(nil, time.Hour), cookie)
+	assert.Equal(t, ErrSession, err)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	_, err = s.Read(req)
+	assert.Equal(t, ErrSession, err)
+}
+
+func Test_Auth_SessionSecure(t *testing.T) {
+	s := NewSessions([]byte("secret"), time.Hour)
+	assert.False(t, issue(s, &User{Name: "ash", Role: Viewer}).Secure)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	s.Issue(rec, req, &User{Name: "ash", Role: Viewer})
+	assert.True(t, rec.Result().Cookies()[0].Secure)
+
+	SecureCookies = true
+	defer func() {
+		SecureCookies = false
+	}()
+	assert.True(t, issue(s, &User{Name: "ash", Role: Viewer}).Secure)
+}
+
+func Test_Auth_SessionTampered(t *testing.T) {
+	s := NewSessions([]byte("secret"), time.Hour)
+	cookie := issue(s, &User{Name: "jc"})
+	other := issue(s, &User{Name: "admin"})
+
+	// the payload of one session with the signature of another
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	cookie.Value = strings.SplitN(other.Value, ".", 2)[0] + "." + parts[1]
+	_, err := read(s, cookie)
+	assert.Equal(t, ErrSession, err)
+
+	cookie.Value = parts[0]
+	_, err = read(s, cookie)
+	assert.Equal(t, ErrSession, err)
+}
+
+func Test_Auth_SessionExpired(t *testing.T) {
+	s := NewSessions([]byte("secret"), -time.Minute)
+	_, err := read(s, issue(s, &User{Name: "jc"}))
+	assert.Equal(t, ErrSession, err)
+}
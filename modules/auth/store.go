@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role is what a user is allowed to do, each role includes the ones below it.
+type Role int
+
+const (
+	Anonymous Role = iota
+	Viewer
+	Editor
+	Admin
+)
+
+var roles = []string{"anonymous", "viewer", "editor", "admin"}
+
+func (r Role) String() string {
+	if r < Anonymous || int(r) >= len(roles) {
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+	return roles[r]
+}
+
+// ParseRole returns the role of a name like "editor".
+func ParseRole(name string) (Role, error) {
+	for i, role := range roles[Viewer:] {
+		if strings.EqualFold(name, role) {
+			return Role(i) + Viewer, nil
+		}
+	}
+	return Anonymous, fmt.Errorf("unknown role %q", name)
+}
+
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Role) UnmarshalText(text []byte) (err error) {
+	*r, err = ParseRole(string(text))
+	return err
+}
+
+// User is a user of the frontend, its password is a bcrypt hash.
+type User struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
+// Can returns true if the user has at least role.
+func (u *User) Can(role Role) bool {
+	return u != nil && u.Role >= role
+}
+
+// ErrLogin is returned for unknown users and wrong passwords alike.
+var ErrLogin = errors.New("invalid user name or password")
+
+// dummyHash is checked against for unknown users, so they take as long as known ones.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("moviedb"), bcrypt.DefaultCost)
+
+// Store holds all users, read from a JSON file.
+type Store struct {
+	mutex sync.RWMutex
+	users map[string]*User
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{users: make(map[string]*User)}
+}
+
+// LoadStore returns the store of the JSON file at path. A missing file is an empty store.
+func LoadStore(path string) (*Store, error) {
+	s := NewStore()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("could not read users of %s: %v", path, err)
+	}
+	for _, user := range users {
+		if _, err := bcrypt.Cost([]byte(user.Password)); err != nil {
+			return nil, fmt.Errorf("user %q in %s has no bcrypt password: %v", user.Name, path, err)
+		}
+		s.users[strings.ToLower(user.Name)] = user
+	}
+	return s, nil
+}
+
+// Get returns the user called name.
+func (s *Store) Get(name string) (*User, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	user, ok := s.users[strings.ToLower(name)]
+	return user, ok
+}
+
+// Len returns the number of users.
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.users)
+}
+
+// Put adds the user called name, or replaces its password and role.
+func (s *Store) Put(name, password string, role Role) error {
+	if len(name) == 0 || len(password) == 0 {
+		return errors.New("user name and password must not be empty")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users[strings.ToLower(name)] = &User{Name: name, Password: string(hash), Role: role}
+	return nil
+}
+
+// Authenticate returns the user called name, if password is right.
+func (s *Store) Authenticate(name, password string) (*User, error) {
+	user, ok := s.Get(name)
+	if !ok {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrLogin
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrLogin
+	}
+	return user, nil
+}
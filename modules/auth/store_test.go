@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Auth_Roles(t *testing.T) {
+	role, err := ParseRole("Editor")
+	assert.NoError(t, err)
+	assert.Equal(t, Editor, role)
+	_, err = ParseRole("anonymous")
+	assert.Error(t, err)
+	assert.Equal(t, "admin", Admin.String())
+
+	data, err := json.Marshal(&User{Name: "jc", Role: Viewer})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"jc","password":"","role":"viewer"}`, string(data))
+
+	var user User
+	assert.Error(t, json.Unmarshal([]byte(`{"name":"jc","role":"root"}`), &user))
+
+	admin := &User{Role: Admin}
+	assert.True(t, admin.Can(Editor))
+	assert.False(t, (&User{Role: Viewer}).Can(Editor))
+	assert.False(t, (*User)(nil).Can(Viewer))
+}
+
+func Test_Auth_Store(t *testing.T) {
+	dir, err := ioutil.TempDir("", "moviedb-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.json")
+
+	// a missing file is an empty store
+	store, err := LoadStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, store.Len())
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`[{"name":"JC","password":"`+string(hash)+`","role":"admin"}]`), 0600))
+	store, err = LoadStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	user, err := store.Authenticate("jc", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "JC", user.Name)
+	assert.Equal(t, Admin, user.Role)
+
+	_, err = store.Authenticate("jc", "wrong")
+	assert.Equal(t, ErrLogin, err)
+	_, err = store.Authenticate("someone", "secret")
+	assert.Equal(t, ErrLogin, err)
+
+	assert.NoError(t, store.Put("editor", "editorpw", Editor))
+	assert.Error(t, store.Put("nobody", "", Viewer))
+	user, err = store.Authenticate("editor", "editorpw")
+	assert.NoError(t, err)
+	assert.Equal(t, Editor, user.Role)
+}
+
+func Test_Auth_StorePlainPassword(t *testing.T) {
+	file, err := ioutil.TempFile("", "moviedb-users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`[{"name":"jc","password":"secret","role":"admin"}]`)
+	file.Close()
+
+	_, err = LoadStore(file.Name())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `user "jc"`)
+}
@@ -0,0 +1,127 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned instead of calling the backend while the circuit is open.
+var ErrOpen = errors.New("circuit breaker is open, backend unavailable")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets all requests through, counting consecutive failures.
+	Closed State = iota
+	// Open fails all requests right away, until the cooldown is over.
+	Open
+	// HalfOpen lets a single probe request through, which decides whether to close or open again.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker is a circuit breaker, opening after threshold consecutive failures.
+// It is safe for concurrent use.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    State
+	failures int
+	since    time.Time
+	probing  bool
+}
+
+// New returns a closed Breaker. A threshold of 0 or less disables it, it then never opens.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		since:     time.Now(),
+	}
+}
+
+// Allow returns ErrOpen if a request must not be made right now.
+// Every allowed request has to be followed by either Success, Failure or Cancel.
+func (b *Breaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == Open && time.Since(b.since) >= b.cooldown {
+		b.setState(HalfOpen)
+	}
+	switch {
+	case b.state == Open:
+		return ErrOpen
+	case b.state == HalfOpen && b.probing:
+		return ErrOpen
+	case b.state == HalfOpen:
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a successful request, closing the circuit.
+func (b *Breaker) Success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != Closed {
+		b.setState(Closed)
+	}
+}
+
+// Failure records a failed request, opening the circuit after too many of them.
+func (b *Breaker) Failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.setState(Open)
+	}
+}
+
+// Cancel records a request given up by its caller, which tells nothing about the backend.
+// If it was the probe, the next request probes again.
+func (b *Breaker) Cancel() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+}
+
+func (b *Breaker) setState(state State) {
+	b.state = state
+	b.since = time.Now()
+}
+
+// State returns the current state and since when the breaker is in it.
+func (b *Breaker) State() (State, time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == Open && time.Since(b.since) >= b.cooldown {
+		// the next request is going to probe the backend
+		return HalfOpen, b.since.Add(b.cooldown)
+	}
+	return b.state, b.since
+}
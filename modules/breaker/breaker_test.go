@@ -0,0 +1,233 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Breaker_Opens(t *testing.T) {
+	b := New(3, time.Minute)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+
+	// a success resets the consecutive failures
+	assert.NoError(t, b.Allow())
+	b.Success()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	state, _ = b.State()
+	assert.Equal(t, Open, state)
+	assert.Equal(t, ErrOpen, b.Allow())
+}
+
+func Test_Breaker_HalfOpen(t *testing.T) {
+	b := New(1, 20*time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, ErrOpen, b.Allow())
+
+	time.Sleep(30 * time.Millisecond)
+	state, _ := b.State()
+	assert.Equal(t, HalfOpen, state)
+
+	// a single probe only
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, ErrOpen, b.Allow())
+
+	// failing probe opens again
+	b.Failure()
+	state, _ = b.State()
+	assert.Equal(t, Open, state)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Success()
+	state, _ = b.State()
+	assert.Equal(t, Closed, state)
+	assert.NoError(t, b.Allow())
+}
+
+func Test_Breaker_Cancel(t *testing.T) {
+	b := New(1, 20*time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Failure()
+	time.Sleep(30 * time.Millisecond)
+
+	// a cancelled probe lets the next request probe again, without opening the circuit
+	assert.NoError(t, b.Allow())
+	b.Cancel()
+	state, _ := b.State()
+	assert.Equal(t, HalfOpen, state)
+	assert.NoError(t, b.Allow())
+}
+
+func Test_Breaker_Disabled(t *testing.T) {
+	b := New(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, b.Allow())
+		b.Failure()
+	}
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+}
+
+func Test_Breaker_StateString(t *testing.T) {
+	assert.Equal(t, "closed", Closed.String())
+	assert.Equal(t, "open", Open.String())
+	assert.Equal(t, "half-open", HalfOpen.String())
+}
+
+type server struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests int
+	failures int
+	status   int
+	delay    time.Duration
+}
+
+func newServer(failures, status int, delay time.Duration) *server {
+	s := &server{failures: failures, status: status, delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.mutex.Lock()
+		s.requests++
+		fail := s.requests <= s.failures
+		s.mutex.Unlock()
+
+		time.Sleep(s.delay)
+		if fail {
+			w.WriteHeader(s.status)
+			fmt.Fprint(w, "failed")
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	return s
+}
+
+func (s *server) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+func get(t *Transport, url string) (int, string, error) {
+	client := &http.Client{Transport: t}
+	res, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	return res.StatusCode, string(body), err
+}
+
+func Test_Transport_Retry(t *testing.T) {
+	s := newServer(2, http.StatusServiceUnavailable, 0)
+	defer s.Close()
+
+	status, body, err := get(&Transport{Retries: 2, Backoff: time.Millisecond}, s.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, 3, s.count())
+}
+
+func Test_Transport_RetriesExhausted(t *testing.T) {
+	s := newServer(5, http.StatusInternalServerError, 0)
+	defer s.Close()
+
+	status, body, err := get(&Transport{Retries: 2, Backoff: time.Millisecond}, s.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, "failed", body)
+	assert.Equal(t, 3, s.count())
+}
+
+func Test_Transport_NoRetryOnClientError(t *testing.T) {
+	s := newServer(5, http.StatusNotFound, 0)
+	defer s.Close()
+
+	b := New(1, time.Minute)
+	status, _, err := get(&Transport{Breaker: b, Retries: 2, Backoff: time.Millisecond}, s.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, 1, s.count())
+
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+}
+
+func Test_Transport_NoRetryOnPost(t *testing.T) {
+	s := newServer(5, http.StatusInternalServerError, 0)
+	defer s.Close()
+
+	client := &http.Client{Transport: &Transport{Retries: 2, Backoff: time.Millisecond}}
+	res, err := client.Post(s.URL, "text/plain", nil)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 1, s.count())
+}
+
+func Test_Transport_Timeout(t *testing.T) {
+	s := newServer(0, 0, 100*time.Millisecond)
+	defer s.Close()
+
+	start := time.Now()
+	_, _, err := get(&Transport{Timeout: 20 * time.Millisecond, Retries: 1, Backoff: time.Millisecond}, s.URL)
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 90*time.Millisecond)
+	assert.Equal(t, 2, s.count())
+}
+
+func Test_Transport_Breaker(t *testing.T) {
+	s := newServer(1, http.StatusBadGateway, 0)
+	defer s.Close()
+
+	b := New(1, time.Minute)
+	tr := &Transport{Breaker: b}
+	status, _, err := get(tr, s.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, status)
+
+	// fails fast, without asking the server
+	_, _, err = get(tr, s.URL)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrOpen.Error())
+	assert.Equal(t, 1, s.count())
+}
+
+func Test_Transport_Canceled(t *testing.T) {
+	s := newServer(0, 0, 100*time.Millisecond)
+	defer s.Close()
+
+	b := New(1, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: &Transport{Breaker: b, Retries: 2, Backoff: time.Millisecond}}
+
+	start := time.Now()
+	_, err = client.Do(req.WithContext(ctx))
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 90*time.Millisecond)
+	// the backend did nothing wrong
+	state, _ := b.State()
+	assert.Equal(t, Closed, state)
+	assert.NoError(t, b.Allow())
+}
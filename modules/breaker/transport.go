@@ -0,0 +1,129 @@
+package breaker
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper giving every attempt of a request its own timeout,
+// retrying idempotent requests and failing fast while its Breaker is open.
+// Network errors and 5xx responses count as failures, all other responses as successes.
+type Transport struct {
+	Next    http.RoundTripper
+	Breaker *Breaker
+	Timeout time.Duration // per attempt, 0 means no timeout
+	Retries int           // additional attempts of idempotent requests
+	Backoff time.Duration // before the first retry, doubling with every further one
+}
+
+func (t *Transport) next() http.RoundTripper {
+	if t.Next == nil {
+		return http.DefaultTransport
+	}
+	return t.Next
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Breaker != nil {
+		if err := t.Breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	retries := t.Retries
+	if !idempotent(req) {
+		retries = 0
+	}
+
+	var res *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		res, err = t.attempt(req)
+		if !failed(res, err) || attempt >= retries {
+			break
+		}
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		if !sleep(req.Context(), jitter(t.Backoff<<uint(attempt))) {
+			res, err = nil, req.Context().Err()
+			break
+		}
+	}
+
+	if t.Breaker != nil {
+		switch {
+		case err != nil && req.Context().Err() != nil:
+			t.Breaker.Cancel()
+		case failed(res, err):
+			t.Breaker.Failure()
+		default:
+			t.Breaker.Success()
+		}
+	}
+	return res, err
+}
+
+func (t *Transport) attempt(req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return t.next().RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	res, err := t.next().RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout covers reading the body too
+	res.Body = &cancelBody{res.Body, cancel}
+	return res, nil
+}
+
+func failed(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusInternalServerError
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", "GET", "HEAD", "OPTIONS":
+		return req.Body == nil || req.GetBody != nil
+	}
+	return false
+}
+
+// jitter randomizes d by ±50%, so retries of concurrent requests spread out.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// sleep waits for d, or returns false if ctx is done before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
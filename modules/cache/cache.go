@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+var (
+	log *logrus.Logger
+)
+
+func init() {
+	log = logger.GetLogger()
+}
+
+type entry struct {
+	url        string
+	response   string
+	created    time.Time
+	refreshing bool
+}
+
+// Cache is a size limited, TTL based response cache in front of an api.Getter.
+// Entries older than the TTL but still within the stale period are served as is,
+// while being refreshed in the background (stale-while-revalidate).
+type Cache struct {
+	getter  api.Getter
+	ttl     time.Duration
+	stale   time.Duration
+	size    int
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+	hits    uint64
+	misses  uint64
+	// generation is increased by every Flush, a fetch started
+	// before that must not put its outdated response back
+	generation uint64
+}
+
+// New returns a Cache holding at most size entries. A ttl of 0 disables caching.
+func New(getter api.Getter, ttl, stale time.Duration, size int) *Cache {
+	return &Cache{
+		getter:  getter,
+		ttl:     ttl,
+		stale:   stale,
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the response for url, either from the cache or from the api.Getter.
+// Stale responses are refreshed regardless of ctx, other requests get the refreshed one.
+func (c *Cache) Get(ctx context.Context, url string) (string, error) {
+	c.mutex.Lock()
+	if c.ttl <= 0 || c.size <= 0 {
+		c.misses++
+		c.mutex.Unlock()
+		return c.getter.Get(ctx, url)
+	}
+
+	if element, ok := c.entries[url]; ok {
+		e := element.Value.(*entry)
+		age := c.now().Sub(e.created)
+		switch {
+		case age < c.ttl:
+			c.hits++
+			c.lru.MoveToFront(element)
+			c.mutex.Unlock()
+			return e.response, nil
+		case age < c.ttl+c.stale:
+			c.hits++
+			c.lru.MoveToFront(element)
+			if !e.refreshing {
+				e.refreshing = true
+				go c.refresh(e)
+			}
+			c.mutex.Unlock()
+			return e.response, nil
+		default:
+			c.remove(element)
+		}
+	}
+	c.misses++
+	generation := c.generation
+	c.mutex.Unlock()
+
+	response, err := c.getter.Get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	c.set(url, response, generation)
+	return response, nil
+}
+
+// Flush removes all cached responses.
+func (c *Cache) Flush() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of cached responses.
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// Stats returns how many Get calls were answered from the cache, and how many were not.
+func (c *Cache) Stats() (hits, misses uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.hits, c.misses
+}
+
+// refresh fetches the response of e again. It only replaces the response of e while e
+// is still cached, once removed, e may be outdated and must not be put back.
+func (c *Cache) refresh(e *entry) {
+	response, err := c.getter.Get(context.Background(), e.url)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"url":   e.url,
+		}).Warn("Could not refresh cached backend response")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e.refreshing = false
+	if element, ok := c.entries[e.url]; ok && element.Value == e && err == nil {
+		e.response = response
+		e.created = c.now()
+		c.lru.MoveToFront(element)
+	}
+}
+
+// set caches the response for url, unless it was fetched before responses were removed.
+func (c *Cache) set(url, response string, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.entries[url]; ok {
+		e := element.Value.(*entry)
+		e.response = response
+		e.created = c.now()
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[url] = c.lru.PushFront(&entry{
+		url:      url,
+		response: response,
+		created:  c.now(),
+	})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*entry).url)
+}
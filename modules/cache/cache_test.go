@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type getter struct {
+	mutex sync.Mutex
+	calls map[string]int
+	err   error
+}
+
+func (g *getter) Get(ctx context.Context, url string) (string, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.err != nil {
+		return "", g.err
+	}
+	g.calls[url]++
+	return fmt.Sprintf("%s#%d", url, g.calls[url]), nil
+}
+
+func (g *getter) count(url string) int {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.calls[url]
+}
+
+func newGetter() *getter {
+	return &getter{calls: make(map[string]int)}
+}
+
+func Test_Cache_Hit(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 10)
+
+	response, err := c.Get(context.Background(), "/movies")
+	assert.NoError(t, err)
+	assert.Equal(t, "/movies#1", response)
+
+	response, err = c.Get(context.Background(), "/movies")
+	assert.NoError(t, err)
+	assert.Equal(t, "/movies#1", response)
+	assert.Equal(t, 1, g.count("/movies"))
+
+	hits, misses := c.Stats()
+	assert.Equal(t, uint64(1), hits)
+	assert.Equal(t, uint64(1), misses)
+}
+
+func Test_Cache_Disabled(t *testing.T) {
+	g := newGetter()
+	c := New(g, 0, 0, 10)
+
+	c.Get(context.Background(), "/movies")
+	response, _ := c.Get(context.Background(), "/movies")
+	assert.Equal(t, "/movies#2", response)
+	assert.Equal(t, 0, c.Len())
+}
+
+func Test_Cache_StaleWhileRevalidate(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Get(context.Background(), "/statistics")
+	now = now.Add(90 * time.Second)
+
+	response, err := c.Get(context.Background(), "/statistics")
+	assert.NoError(t, err)
+	assert.Equal(t, "/statistics#1", response)
+
+	for i := 0; i < 100 && g.count("/statistics") < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	response, _ = c.Get(context.Background(), "/statistics")
+	assert.Equal(t, "/statistics#2", response)
+}
+
+func Test_Cache_Expired(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Get(context.Background(), "/movie/1")
+	now = now.Add(3 * time.Minute)
+
+	response, _ := c.Get(context.Background(), "/movie/1")
+	assert.Equal(t, "/movie/1#2", response)
+}
+
+func Test_Cache_SizeLimit(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 2)
+
+	c.Get(context.Background(), "/movie/1")
+	c.Get(context.Background(), "/movie/2")
+	c.Get(context.Background(), "/movie/1")
+	c.Get(context.Background(), "/movie/3")
+	assert.Equal(t, 2, c.Len())
+
+	c.Get(context.Background(), "/movie/1")
+	assert.Equal(t, 1, g.count("/movie/1"))
+	c.Get(context.Background(), "/movie/2")
+	assert.Equal(t, 2, g.count("/movie/2"))
+}
+
+func Test_Cache_Flush(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 10)
+
+	c.Get(context.Background(), "/movie/1")
+	c.Get(context.Background(), "/movies?query=genre&value=1")
+	c.Flush()
+	assert.Equal(t, 0, c.Len())
+
+	response, _ := c.Get(context.Background(), "/movie/1")
+	assert.Equal(t, "/movie/1#2", response)
+}
+
+func Test_Cache_FlushDuringRefresh(t *testing.T) {
+	g := newGetter()
+	c := New(g, time.Minute, time.Minute, 10)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Get(context.Background(), "/statistics")
+	refreshing := c.entries["/statistics"].Value.(*entry)
+	refreshing.refreshing = true
+	c.Flush()
+	// the refreshed response is not put back, it may have been fetched before the change
+	c.refresh(refreshing)
+	assert.Equal(t, 0, c.Len())
+
+	response, _ := c.Get(context.Background(), "/statistics")
+	assert.Equal(t, "/statistics#3", response)
+	now = now.Add(90 * time.Second)
+	c.Get(context.Background(), "/statistics")
+	for i := 0; i < 100 && g.count("/statistics") < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	response, _ = c.Get(context.Background(), "/statistics")
+	assert.Equal(t, "/statistics#4", response)
+}
+
+// slowGetter answers once it is released.
+type slowGetter struct {
+	started chan string
+	release chan struct{}
+}
+
+func (g *slowGetter) Get(ctx context.Context, url string) (string, error) {
+	g.started <- url
+	<-g.release
+	return url, nil
+}
+
+func Test_Cache_FlushDuringGet(t *testing.T) {
+	g := &slowGetter{make(chan string, 10), make(chan struct{})}
+	c := New(g, time.Minute, time.Minute, 10)
+
+	done := make(chan string)
+	go func() {
+		response, _ := c.Get(context.Background(), "/movie/1")
+		done <- response
+	}()
+	<-g.started
+	c.Flush()
+	close(g.release)
+
+	// the response fetched before the flush is returned, but not cached
+	assert.Equal(t, "/movie/1", <-done)
+	assert.Equal(t, 0, c.Len())
+
+	c.Get(context.Background(), "/movie/1")
+	assert.Equal(t, 1, c.Len())
+}
+
+func Test_Cache_Error(t *testing.T) {
+	g := newGetter()
+	g.err = fmt.Errorf("backend down")
+	c := New(g, time.Minute, time.Minute, 10)
+
+	_, err := c.Get(context.Background(), "/movies")
+	assert.Error(t, err)
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,133 @@
+package canonical
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// pairs are parameters which only make sense together, they are kept next to each other.
+var pairs = [][2]string{{"query", "value"}, {"sort", "by"}}
+
+// singles are the parameters following the pairs, in this order. All others come last, sorted by key.
+var singles = []string{"format", "view", "page", "per_page"}
+
+// Params returns the canonical parameters of a request, without duplicates, unknown parameters
+// or invalid values. Requests with parameters that can't be made canonical are an error.
+type Params func(url.Values) (url.Values, error)
+
+// Redirect permanently redirects GET and HEAD requests to their canonical URL, if they are not there yet.
+// Requests with invalid parameters are left to next, to respond with its error page.
+func Redirect(params Params, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			next.ServeHTTP(w, req)
+			return
+		}
+		canonical, err := params(req.URL.Query())
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if query := Encode(canonical); !same(req.URL.RawQuery, query) {
+			http.Redirect(w, req, Path(req.URL.Path, query), http.StatusMovedPermanently)
+			return
+		}
+		next.ServeHTTP(w, req)
+	}
+}
+
+// Merge returns all values in one, later ones adding to earlier ones.
+func Merge(values ...url.Values) url.Values {
+	merged := url.Values{}
+	for _, v := range values {
+		for key, vs := range v {
+			merged[key] = append(merged[key], vs...)
+		}
+	}
+	return merged
+}
+
+// Encode encodes values like url.Values.Encode, but in canonical order: the query/value and sort/by pairs first,
+// alternating like they belong together, then format, view and the page, and then all others sorted by key.
+func Encode(values url.Values) string {
+	var parts []string
+	add := func(key, value string) {
+		parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+	}
+
+	done := make(map[string]bool)
+	for _, pair := range pairs {
+		first, second := values[pair[0]], values[pair[1]]
+		for i := 0; i < len(first) || i < len(second); i++ {
+			if i < len(first) {
+				add(pair[0], first[i])
+			}
+			if i < len(second) {
+				add(pair[1], second[i])
+			}
+		}
+		done[pair[0]], done[pair[1]] = true, true
+	}
+	for _, key := range singles {
+		for _, value := range values[key] {
+			add(key, value)
+		}
+		done[key] = true
+	}
+
+	var others []string
+	for key := range values {
+		if !done[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		for _, value := range values[key] {
+			add(key, value)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Path returns path with the encoded query, if there is any.
+func Path(path, query string) string {
+	if len(query) > 0 {
+		return path + "?" + query
+	}
+	return path
+}
+
+// same reports whether both raw queries have the same parameters in the same order,
+// no matter how they are escaped.
+func same(rawQuery, query string) bool {
+	a, b := decode(rawQuery), decode(query)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func decode(rawQuery string) []string {
+	var params []string
+	for _, part := range strings.Split(rawQuery, "&") {
+		if len(part) == 0 {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		key, _ := url.QueryUnescape(kv[0])
+		var value string
+		if len(kv) > 1 {
+			value, _ = url.QueryUnescape(kv[1])
+		}
+		params = append(params, key, value)
+	}
+	return params
+}
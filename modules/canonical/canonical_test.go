@@ -0,0 +1,66 @@
+package canonical
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Canonical_Encode(t *testing.T) {
+	values := url.Values{
+		"utm_source": {"feed"},
+		"page":       {"2"},
+		"by":         {"desc", "asc"},
+		"view":       {"grid"},
+		"value":      {"16:9", "Kill Bill"},
+		"sort":       {"year", "title"},
+		"query":      {"format", "title"},
+		"format":     {"json"},
+		"a":          {"1"},
+	}
+	assert.Equal(t, "query=format&value=16%3A9&query=title&value=Kill+Bill&sort=year&by=desc&sort=title&by=asc"+
+		"&format=json&view=grid&page=2&a=1&utm_source=feed", Encode(values))
+	assert.Equal(t, "", Encode(url.Values{}))
+}
+
+func Test_Canonical_Merge(t *testing.T) {
+	assert.Equal(t, url.Values{"sort": {"title", "year"}, "page": {"2"}},
+		Merge(url.Values{"sort": {"title"}}, url.Values{"sort": {"year"}, "page": {"2"}}))
+}
+
+func Test_Canonical_Redirect(t *testing.T) {
+	params := func(values url.Values) (url.Values, error) {
+		if values.Get("sort") == "invalid" {
+			return nil, errors.New("invalid sort")
+		}
+		return url.Values{"sort": values["sort"]}, nil
+	}
+	handler := Redirect(params, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		handler(response, httptest.NewRequest(method, target, nil))
+		return response
+	}
+
+	response := serve("GET", "/movies?foo=bar&sort=title")
+	assert.Equal(t, http.StatusMovedPermanently, response.Code)
+	assert.Equal(t, "/movies?sort=title", response.Header().Get("Location"))
+
+	response = serve("GET", "/movies?foo=bar")
+	assert.Equal(t, http.StatusMovedPermanently, response.Code)
+	assert.Equal(t, "/movies", response.Header().Get("Location"))
+
+	// the escaping doesn't matter
+	assert.Equal(t, http.StatusTeapot, serve("GET", "/movies?sort=16%3a9").Code)
+	assert.Equal(t, http.StatusTeapot, serve("GET", "/movies?sort=Kill%20Bill").Code)
+	assert.Equal(t, http.StatusTeapot, serve("GET", "/movies").Code)
+
+	assert.Equal(t, http.StatusTeapot, serve("GET", "/movies?sort=invalid&foo=bar").Code)
+	assert.Equal(t, http.StatusTeapot, serve("POST", "/movies?foo=bar").Code)
+}
@@ -0,0 +1,204 @@
+package covers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+var log *logrus.Logger
+
+func init() {
+	log = logger.GetLogger()
+}
+
+const (
+	// maxBytes is the largest original image accepted from the origin.
+	maxBytes = 20 << 20
+	// maxPixels is the largest original image decoded, larger ones would take too much memory.
+	maxPixels = 40 * 1000 * 1000
+)
+
+// Size is a variant of the covers, scaled down to fit into Width×Height.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Sizes are all variants, by their name.
+var Sizes = map[string]Size{
+	"thumb": {"thumb", 92, 138},
+	"card":  {"card", 185, 278},
+	"full":  {"full", 500, 750},
+}
+
+// ErrName is returned for names that are no image file, like a path.
+var ErrName = errors.New("not a cover image name")
+
+var names = regexp.MustCompile(`^[A-Za-z0-9_.-]+\.(jpg|jpeg|png|gif)$`)
+
+// Covers serves the cover images of an Origin in all Sizes, cached on disk as JPEG.
+type Covers struct {
+	origin Origin
+	dir    string
+	ttl    time.Duration
+
+	// locks make sure every variant is only resized once at a time
+	locks        [64]sync.Mutex
+	placeholders map[string][]byte
+}
+
+// New returns the Covers of origin, cached in dir for ttl. With a ttl of 0 they are cached forever.
+func New(origin Origin, dir string, ttl time.Duration) *Covers {
+	c := &Covers{
+		origin:       origin,
+		dir:          dir,
+		ttl:          ttl,
+		placeholders: make(map[string][]byte),
+	}
+	for name, size := range Sizes {
+		data, err := encode(placeholder(size))
+		if err != nil {
+			panic(err)
+		}
+		c.placeholders[name] = data
+	}
+	return c
+}
+
+// ServeHTTP serves the cover {name} in {size}, or full size if the route has none.
+// Missing covers get a placeholder, so listings never show a broken image.
+func (c *Covers) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	size, ok := Sizes[vars["size"]]
+	if !ok {
+		size = Sizes["full"]
+	}
+
+	cacheControl := "public, max-age=86400"
+	data, err := c.Get(size, vars["name"])
+	if err != nil {
+		if !os.IsNotExist(err) && err != ErrName {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"name":  vars["name"],
+				"size":  size.Name,
+			}).Error("Could not get cover")
+		}
+		// the cover might show up soon, i.e. after it has been uploaded
+		data, cacheControl = c.placeholders[size.Name], "public, max-age=300"
+	}
+
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Content-Type", "image/jpeg")
+	// handles If-None-Match by the ETag
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+}
+
+// Get returns the cover called name in size, as JPEG. It is resized only if it is not cached yet.
+func (c *Covers) Get(size Size, name string) ([]byte, error) {
+	if !names.MatchString(name) {
+		return nil, ErrName
+	}
+
+	path := filepath.Join(c.dir, size.Name, name+".jpg")
+	lock := c.lock(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if info, err := os.Stat(path); err == nil && (c.ttl <= 0 || time.Since(info.ModTime()) < c.ttl) {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := c.resize(size, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := store(path, data); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  path,
+		}).Warn("Could not cache cover")
+	}
+	return data, nil
+}
+
+func (c *Covers) lock(path string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return &c.locks[h.Sum32()%uint32(len(c.locks))]
+}
+
+// resize reads the original cover from the origin and scales it down to size.
+func (c *Covers) resize(size Size, name string) ([]byte, error) {
+	r, err := c.origin.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	original, err := ioutil.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(original) > maxBytes {
+		return nil, fmt.Errorf("cover %s is larger than %d bytes", name, maxBytes)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(original))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cover %s: %v", name, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("cover %s is too large, %dx%d", name, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cover %s: %v", name, err)
+	}
+
+	width, height := fit(img.Bounds().Dx(), img.Bounds().Dy(), size)
+	return encode(resize(img, width, height))
+}
+
+// store writes data to path and renames it into place, so no half written file is ever read.
+func store(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".cover")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,247 @@
+package covers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingOrigin is a directory origin counting how often each image is opened.
+type countingOrigin struct {
+	DirOrigin
+	mutex sync.Mutex
+	opens map[string]int
+}
+
+func (o *countingOrigin) Open(name string) (io.ReadCloser, error) {
+	o.mutex.Lock()
+	o.opens[name]++
+	o.mutex.Unlock()
+	return o.DirOrigin.Open(name)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "moviedb-covers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writePNG writes a w×h image, red on the left half and blue on the right one.
+func writePNG(t *testing.T, path string, w, h int) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{0xff, 0, 0, 0xff})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 0xff, 0xff})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func Test_Covers_Fit(t *testing.T) {
+	size := Size{"card", 185, 278}
+	assert.Equal(t, [2]int{100, 150}, pair(fit(100, 150, size)))
+	assert.Equal(t, [2]int{185, 277}, pair(fit(1000, 1500, size)))
+	assert.Equal(t, [2]int{185, 92}, pair(fit(2000, 1000, size)))
+	assert.Equal(t, [2]int{1, 278}, pair(fit(1, 5000, size)))
+}
+
+func pair(a, b int) [2]int {
+	return [2]int{a, b}
+}
+
+func Test_Covers_Resize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.Set(x, 0, color.NRGBA{0xff, 0, 0, 0xff})
+		src.Set(x, 1, color.NRGBA{0, 0, 0xff, 0xff})
+	}
+	// the right half is transparent and becomes white
+	src.Set(3, 0, color.NRGBA{0, 0, 0, 0})
+	src.Set(3, 1, color.NRGBA{0, 0, 0, 0})
+
+	dst := resize(src, 2, 1)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, color.RGBA{0x7f, 0, 0x7f, 0xff}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{0xbf, 0x80, 0xbf, 0xff}, dst.RGBAAt(1, 0))
+}
+
+func Test_Covers_Get(t *testing.T) {
+	originDir, cacheDir := tempDir(t), tempDir(t)
+	defer os.RemoveAll(originDir)
+	defer os.RemoveAll(cacheDir)
+	writePNG(t, filepath.Join(originDir, "movie.png"), 400, 600)
+
+	origin := &countingOrigin{DirOrigin: DirOrigin(originDir), opens: make(map[string]int)}
+	c := New(origin, cacheDir, 0)
+
+	data, err := c.Get(Sizes["thumb"], "movie.png")
+	assert.NoError(t, err)
+	img := decode(t, data)
+	assert.Equal(t, image.Rect(0, 0, 92, 138), img.Bounds())
+	r, _, b, _ := img.At(10, 60).RGBA()
+	assert.True(t, r > 0xf000 && b < 0x1000)
+
+	// cached on disk, the origin is not asked again
+	cached, err := c.Get(Sizes["thumb"], "movie.png")
+	assert.NoError(t, err)
+	assert.Equal(t, data, cached)
+	assert.Equal(t, 1, origin.opens["movie.png"])
+	_, err = os.Stat(filepath.Join(cacheDir, "thumb", "movie.png.jpg"))
+	assert.NoError(t, err)
+
+	// every size is its own variant
+	data, err = c.Get(Sizes["full"], "movie.png")
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 400, 600), decode(t, data).Bounds())
+	assert.Equal(t, 2, origin.opens["movie.png"])
+
+	_, err = c.Get(Sizes["thumb"], "missing.jpg")
+	assert.True(t, os.IsNotExist(err))
+	for _, name := range []string{"", "../movie.png", "movie.txt", "sub/movie.png"} {
+		_, err = c.Get(Sizes["thumb"], name)
+		assert.Equal(t, ErrName, err, name)
+	}
+}
+
+func Test_Covers_Expired(t *testing.T) {
+	originDir, cacheDir := tempDir(t), tempDir(t)
+	defer os.RemoveAll(originDir)
+	defer os.RemoveAll(cacheDir)
+	writePNG(t, filepath.Join(originDir, "movie.png"), 40, 60)
+
+	origin := &countingOrigin{DirOrigin: DirOrigin(originDir), opens: make(map[string]int)}
+	c := New(origin, cacheDir, time.Hour)
+	c.Get(Sizes["card"], "movie.png")
+
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(filepath.Join(cacheDir, "card", "movie.png.jpg"), old, old)
+	c.Get(Sizes["card"], "movie.png")
+	assert.Equal(t, 2, origin.opens["movie.png"])
+}
+
+func Test_Covers_URLOrigin(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writePNG(t, filepath.Join(dir, "movie.png"), 40, 60)
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	origin := NewOrigin(server.URL + "/")
+	assert.IsType(t, &URLOrigin{}, origin)
+	assert.IsType(t, DirOrigin(""), NewOrigin("public/images/movies"))
+
+	r, err := origin.Open("movie.png")
+	assert.NoError(t, err)
+	r.Close()
+	_, err = origin.Open("missing.png")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_Covers_URLOriginMisses(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, req)
+	}))
+	defer server.Close()
+
+	origin := NewOrigin(server.URL).(*URLOrigin)
+	for i := 0; i < 3; i++ {
+		_, err := origin.Open("missing.png")
+		assert.True(t, os.IsNotExist(err))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// until they expire
+	origin.misses["missing.png"] = time.Now().Add(-origin.MissTTL)
+	_, err := origin.Open("missing.png")
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	// made up names never take more than maxMisses
+	for i := 0; i <= maxMisses; i++ {
+		origin.miss(fmt.Sprintf("%d.png", i))
+	}
+	assert.True(t, len(origin.misses) <= maxMisses)
+}
+
+func serve(c *Covers, path string, header http.Header) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.Handle("/covers/{size:thumb|card|full}/{name:[^/]*}", c)
+	router.Handle("/images/movies/{name}", c)
+
+	req, _ := http.NewRequest("GET", path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Covers_ServeHTTP(t *testing.T) {
+	originDir, cacheDir := tempDir(t), tempDir(t)
+	defer os.RemoveAll(originDir)
+	defer os.RemoveAll(cacheDir)
+	writePNG(t, filepath.Join(originDir, "movie.png"), 1000, 1500)
+	c := New(DirOrigin(originDir), cacheDir, 0)
+
+	rec := serve(c, "/covers/card/movie.png", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", rec.Header().Get("Cache-Control"))
+	etag := rec.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `"`) && len(etag) == 18)
+	assert.Equal(t, image.Rect(0, 0, 185, 277), decode(t, rec.Body.Bytes()).Bounds())
+
+	rec = serve(c, "/covers/card/movie.png", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, 0, rec.Body.Len())
+
+	// old links get the full size
+	rec = serve(c, "/images/movies/movie.png", nil)
+	assert.Equal(t, image.Rect(0, 0, 500, 750), decode(t, rec.Body.Bytes()).Bounds())
+
+	for _, path := range []string{"/covers/thumb/missing.jpg", "/covers/thumb/"} {
+		rec = serve(c, path, nil)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "public, max-age=300", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, c.placeholders["thumb"], rec.Body.Bytes())
+		assert.Equal(t, image.Rect(0, 0, 92, 138), decode(t, rec.Body.Bytes()).Bounds())
+	}
+}
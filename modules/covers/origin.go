@@ -0,0 +1,107 @@
+package covers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Origin is where the original cover images come from.
+type Origin interface {
+	// Open returns the original image called name, or an error satisfying os.IsNotExist.
+	Open(name string) (io.ReadCloser, error)
+}
+
+// NewOrigin returns a URL origin if location is an http(s) URL, otherwise a directory origin.
+func NewOrigin(location string) Origin {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &URLOrigin{
+			URL:    strings.TrimSuffix(location, "/"),
+			Client: &http.Client{Timeout: 10 * time.Second},
+			// as long as browsers cache the placeholder
+			MissTTL: 5 * time.Minute,
+		}
+	}
+	return DirOrigin(location)
+}
+
+// DirOrigin reads the images of a local directory.
+type DirOrigin string
+
+func (d DirOrigin) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.Base(name)))
+}
+
+// maxMisses is how many missing images a URLOrigin remembers at most.
+const maxMisses = 10000
+
+// URLOrigin fetches the images from below a URL. Images it doesn't have are not asked
+// for again during MissTTL, anybody can request any name.
+type URLOrigin struct {
+	URL     string
+	Client  *http.Client
+	MissTTL time.Duration
+
+	mutex  sync.Mutex
+	misses map[string]time.Time
+}
+
+func (u *URLOrigin) Open(name string) (io.ReadCloser, error) {
+	notExist := &os.PathError{Op: "get", Path: u.URL + "/" + name, Err: os.ErrNotExist}
+	if u.missing(name) {
+		return nil, notExist
+	}
+
+	resp, err := u.Client.Get(u.URL + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		u.miss(name)
+		return nil, notExist
+	case resp.StatusCode != http.StatusOK:
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not get %s/%s: %s", u.URL, name, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// missing returns true if the image called name was missing less than MissTTL ago.
+func (u *URLOrigin) missing(name string) bool {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	since, ok := u.misses[name]
+	return ok && time.Since(since) < u.MissTTL
+}
+
+// miss remembers that the image called name is missing. Once too many are, the expired ones
+// are forgotten, or all of them, so made up names can't use up the memory.
+func (u *URLOrigin) miss(name string) {
+	if u.MissTTL <= 0 {
+		return
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if len(u.misses) >= maxMisses {
+		for n, since := range u.misses {
+			if time.Since(since) >= u.MissTTL {
+				delete(u.misses, n)
+			}
+		}
+	}
+	if u.misses == nil || len(u.misses) >= maxMisses {
+		u.misses = make(map[string]time.Time)
+	}
+	u.misses[name] = time.Now()
+}
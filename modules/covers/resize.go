@@ -0,0 +1,100 @@
+package covers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+const quality = 85
+
+// fit returns the size of a w×h image scaled down to fit into size, keeping its aspect ratio.
+// Images are never scaled up.
+func fit(w, h int, size Size) (int, int) {
+	if w <= size.Width && h <= size.Height {
+		return w, h
+	}
+	if w*size.Height > h*size.Width {
+		return size.Width, atLeastOne(h * size.Width / w)
+	}
+	return atLeastOne(w * size.Height / h), size.Height
+}
+
+// resize scales src down to width×height by averaging all source pixels covering a target pixel,
+// flattened onto white, since JPEG has no transparency.
+func resize(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := span(b.Min.Y, b.Dy(), height, y)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(b.Min.X, b.Dx(), width, x)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// the colors are alpha-premultiplied, white shows through the transparent part
+			white := 0xffff - a/n
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r/n + white) >> 8),
+				G: uint8((g/n + white) >> 8),
+				B: uint8((bl/n + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by target pixel i of n.
+func span(min, length, n, i int) (int, int) {
+	from := min + i*length/n
+	to := min + (i+1)*length/n
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// placeholder returns a neutral cover of size, for movies without a picture.
+func placeholder(size Size) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size.Width, size.Height))
+	background := color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	frame := color.RGBA{0xbb, 0xbb, 0xbb, 0xff}
+
+	border := atLeastOne(size.Width / 24)
+	for y := 0; y < size.Height; y++ {
+		for x := 0; x < size.Width; x++ {
+			img.SetRGBA(x, y, background)
+			inner := x >= border*3 && x < size.Width-border*3 && y >= size.Height/3 && y < size.Height*2/3
+			edge := x < border*4 || x >= size.Width-border*4 || y < size.Height/3+border || y >= size.Height*2/3-border
+			// a simple frame, like an empty picture
+			if inner && edge {
+				img.SetRGBA(x, y, frame)
+			}
+		}
+	}
+	return img
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
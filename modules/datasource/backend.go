@@ -0,0 +1,126 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/fallback"
+)
+
+// BackendSource reads the movie data of moviedb-backend through a getter, like a cache.Cache,
+// and writes it straight through the client. While the backend is down, the getter may
+// return stale responses as *fallback.StaleError, they are read as usual, see WithStale.
+type BackendSource struct {
+	getter api.Getter
+	client Client
+	api    *api.Client
+}
+
+// NewBackend returns the Source of the backend at backendUrl.
+func NewBackend(getter api.Getter, client Client, backendUrl string) *BackendSource {
+	return &BackendSource{
+		getter: getter,
+		client: client,
+		api:    api.New(backendUrl),
+	}
+}
+
+func (b *BackendSource) Movies(ctx context.Context, listing *api.Listing) ([]moviedb.MovieListing, error) {
+	url, err := b.api.Movies(listing)
+	if err != nil {
+		return nil, err
+	}
+	var movies []moviedb.MovieListing
+	return movies, b.get(ctx, url, &movies)
+}
+
+func (b *BackendSource) Movie(ctx context.Context, id int) (*moviedb.Movie, error) {
+	var movie moviedb.Movie
+	if err := b.get(ctx, b.api.Movie(id), &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+func (b *BackendSource) Person(ctx context.Context, id int) (*moviedb.Person, error) {
+	var person moviedb.Person
+	if err := b.get(ctx, b.api.Person(id), &person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+func (b *BackendSource) Actors(ctx context.Context, page api.Page) ([]moviedb.Person, error) {
+	var actors []moviedb.Person
+	return actors, b.get(ctx, b.api.Actors(page), &actors)
+}
+
+func (b *BackendSource) Directors(ctx context.Context, page api.Page) ([]moviedb.Person, error) {
+	var directors []moviedb.Person
+	return directors, b.get(ctx, b.api.Directors(page), &directors)
+}
+
+func (b *BackendSource) Genres(ctx context.Context) ([]moviedb.Genre, error) {
+	var genres []moviedb.Genre
+	return genres, b.get(ctx, b.api.Genres(), &genres)
+}
+
+func (b *BackendSource) Languages(ctx context.Context) ([]moviedb.Language, error) {
+	var languages []moviedb.Language
+	return languages, b.get(ctx, b.api.Languages(), &languages)
+}
+
+func (b *BackendSource) Statistics(ctx context.Context) (*moviedb.Statistics, error) {
+	var stats moviedb.Statistics
+	if err := b.get(ctx, b.api.Statistics(), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (b *BackendSource) AddMovie(ctx context.Context, movie *moviedb.Movie) error {
+	data, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+	response, err := b.client.Post(ctx, b.api.NewMovie(), string(data))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(response), movie)
+}
+
+func (b *BackendSource) SaveMovie(ctx context.Context, movie *moviedb.Movie) error {
+	data, err := json.Marshal(movie)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.Put(ctx, b.api.Movie(movie.Id), string(data))
+	return err
+}
+
+func (b *BackendSource) DeleteMovie(ctx context.Context, id int) error {
+	_, err := b.client.Delete(ctx, b.api.Movie(id))
+	return err
+}
+
+// get decodes the response of url into value, even a stale one.
+func (b *BackendSource) get(ctx context.Context, url string, value interface{}) error {
+	response, err := b.getter.Get(ctx, url)
+	if stale, ok := err.(*fallback.StaleError); ok {
+		log.WithFields(logrus.Fields{
+			"error": stale.Err,
+			"url":   url,
+		}).Warn("Backend unavailable, serving stale response")
+
+		markStale(ctx, stale.Record.Updated)
+		response, err = stale.Record.Response, nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(response), value)
+}
@@ -0,0 +1,96 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/fallback"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	requests []string
+}
+
+func (c *fakeClient) Get(ctx context.Context, url string) (string, error) {
+	c.requests = append(c.requests, "GET "+url)
+	switch url {
+	case "http://backend/movies?query=genre&value=23&sort=title&by=asc":
+		return `[{"id":1026,"title":"Army of Darkness"}]`, nil
+	case "http://backend/movie/1026":
+		return `{"id":1026,"title":"Army of Darkness","genres":[{"id":23,"name":"Horror"}]}`, nil
+	}
+	return "", fmt.Errorf("unknown url %s", url)
+}
+
+func (c *fakeClient) Post(ctx context.Context, url, data string) (string, error) {
+	c.requests = append(c.requests, "POST "+url+" "+data)
+	return `{"id":1027,"title":"Evil Dead II"}`, nil
+}
+
+func (c *fakeClient) Put(ctx context.Context, url, data string) (string, error) {
+	c.requests = append(c.requests, "PUT "+url)
+	return data, nil
+}
+
+func (c *fakeClient) Delete(ctx context.Context, url string) (string, error) {
+	c.requests = append(c.requests, "DELETE "+url)
+	return "", nil
+}
+
+func Test_DataSource_Backend(t *testing.T) {
+	client := &fakeClient{}
+	source := NewBackend(client, client, "http://backend/")
+	ctx := context.Background()
+
+	movies, err := source.Movies(ctx, api.Movies().Where(api.Genre, "23").OrderBy(api.Title, api.Asc))
+	assert.NoError(t, err)
+	assert.Equal(t, []moviedb.MovieListing{{Id: 1026, Title: "Army of Darkness"}}, movies)
+	movie, err := source.Movie(ctx, 1026)
+	assert.NoError(t, err)
+	assert.Equal(t, "Horror", movie.Genres[0].Name)
+
+	// invalid listings never reach the backend
+	_, err = source.Movies(ctx, api.Movies().Where(api.Genre, "horror"))
+	assert.True(t, errors.Is(err, api.ErrInvalid))
+
+	movie = &moviedb.Movie{Title: "Evil Dead II"}
+	assert.NoError(t, source.AddMovie(ctx, movie))
+	assert.Equal(t, 1027, movie.Id)
+	assert.NoError(t, source.SaveMovie(ctx, movie))
+	assert.NoError(t, source.DeleteMovie(ctx, 1027))
+	if assert.Len(t, client.requests, 5) {
+		assert.True(t, strings.HasPrefix(client.requests[2], `POST http://backend/movie {"id":0,"title":"Evil Dead II",`))
+		assert.Equal(t, "PUT http://backend/movie/1027", client.requests[3])
+		assert.Equal(t, "DELETE http://backend/movie/1027", client.requests[4])
+	}
+}
+
+func Test_DataSource_BackendStale(t *testing.T) {
+	updated := time.Date(2015, 6, 14, 0, 0, 0, 0, time.UTC)
+	getter := api.GetterFunc(func(ctx context.Context, url string) (string, error) {
+		return "", &fallback.StaleError{
+			Record: &fallback.Record{URL: url, Response: `{"id":5211,"name":"Quentin Tarantino"}`, Updated: updated},
+			Err:    errors.New("backend down"),
+		}
+	})
+	source := NewBackend(getter, nil, "http://backend")
+
+	// stale data is read like any other, only those asking for it learn that it is stale
+	person, err := source.Person(context.Background(), 5211)
+	assert.NoError(t, err)
+	assert.Equal(t, "Quentin Tarantino", person.Name)
+
+	var since time.Time
+	ctx := WithStale(context.Background(), func(t time.Time) { since = t })
+	person, err = source.Person(ctx, 5211)
+	assert.NoError(t, err)
+	assert.Equal(t, "Quentin Tarantino", person.Name)
+	assert.Equal(t, updated, since)
+}
@@ -0,0 +1,106 @@
+package datasource
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+// HTTPClient requests moviedb-backend through a web.BackendClient, which authenticates them.
+// Unlike the BackendClient, every request is given up once its context is done, which frees
+// the connection and the circuit breaker for requests somebody still waits for.
+type HTTPClient struct {
+	backend  *web.BackendClient
+	mutex    sync.Mutex
+	next     uint64
+	contexts map[string]context.Context
+}
+
+// NewHTTPClient returns the HTTPClient of backend. It wraps the transport of backend, which
+// must not be replaced afterwards, so it sends every request with the context it is made with.
+func NewHTTPClient(backend *web.BackendClient) *HTTPClient {
+	c := &HTTPClient{
+		backend:  backend,
+		contexts: make(map[string]context.Context),
+	}
+	client := backend.HttpClient()
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &contextTransport{client: c, next: next}
+	return c
+}
+
+func (c *HTTPClient) Get(ctx context.Context, url string) (string, error) {
+	url, done := c.with(ctx, url)
+	defer done()
+	return c.backend.Get(url)
+}
+
+func (c *HTTPClient) Post(ctx context.Context, url, data string) (string, error) {
+	url, done := c.with(ctx, url)
+	defer done()
+	return c.backend.Post(url, data)
+}
+
+func (c *HTTPClient) Put(ctx context.Context, url, data string) (string, error) {
+	url, done := c.with(ctx, url)
+	defer done()
+	return c.backend.Put(url, data)
+}
+
+func (c *HTTPClient) Delete(ctx context.Context, url string) (string, error) {
+	url, done := c.with(ctx, url)
+	defer done()
+	return c.backend.Delete(url)
+}
+
+// with returns url marked with a fragment by which the transport finds ctx. Fragments are
+// neither signed nor sent, the BackendClient authenticates the request as if it was url.
+func (c *HTTPClient) with(ctx context.Context, url string) (string, func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.next++
+	key := "ctx-" + strconv.FormatUint(c.next, 10)
+	c.contexts[key] = ctx
+	return url + "#" + key, func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.contexts, key)
+	}
+}
+
+func (c *HTTPClient) context(key string) (context.Context, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ctx, ok := c.contexts[key]
+	return ctx, ok
+}
+
+// contextTransport sends the requests of an HTTPClient with their context.
+type contextTransport struct {
+	client *HTTPClient
+	next   http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(req.URL.Fragment, "ctx-") {
+		return t.next.RoundTrip(req)
+	}
+	ctx, ok := t.client.context(req.URL.Fragment)
+	if !ok {
+		ctx = req.Context()
+	}
+	req = req.WithContext(ctx)
+	u := *req.URL
+	u.Fragment = ""
+	req.URL = &u
+	return t.next.RoundTrip(req)
+}
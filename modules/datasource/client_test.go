@@ -0,0 +1,56 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/stdlib/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DataSource_HTTPClient(t *testing.T) {
+	os.Setenv("JCIO_HTTP_HMAC_SECRET", "secret")
+	defer os.Unsetenv("JCIO_HTTP_HMAC_SECRET")
+
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.NotEmpty(t, req.Header.Get("X-Jcio-Hmac"))
+		if req.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-req.Context().Done():
+			}
+			return
+		}
+		if req.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprintf(w, "%s %s", req.Method, req.URL.Path)
+	}))
+	defer server.Close()
+	client := NewHTTPClient(web.NewBackendClient())
+
+	response, err := client.Get(context.Background(), server.URL+"/genres")
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /genres", response)
+	response, err = client.Post(context.Background(), server.URL+"/movie", `{"title":"Evil Dead II"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST /movie", response)
+	// the backend answers deletes with 204
+	_, err = client.Delete(context.Background(), server.URL+"/movie/1")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Get(ctx, server.URL+"/slow")
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Empty(t, client.contexts)
+}
@@ -0,0 +1,65 @@
+package datasource
+
+import (
+	"context"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+var log *logrus.Logger
+
+func init() {
+	log = logger.GetLogger()
+}
+
+const (
+	// Backend fetches the movie data from moviedb-backend over HTTP.
+	Backend = "backend"
+	// Embedded reads the movie data from a local database, no backend needed.
+	Embedded = "embedded"
+)
+
+// Source is where the movie data comes from, moviedb-backend or an embedded database.
+// Errors are classified like the responses of the backend, see upstream.Error.
+type Source interface {
+	Movies(ctx context.Context, listing *api.Listing) ([]moviedb.MovieListing, error)
+	Movie(ctx context.Context, id int) (*moviedb.Movie, error)
+	Person(ctx context.Context, id int) (*moviedb.Person, error)
+	Actors(ctx context.Context, page api.Page) ([]moviedb.Person, error)
+	Directors(ctx context.Context, page api.Page) ([]moviedb.Person, error)
+	Genres(ctx context.Context) ([]moviedb.Genre, error)
+	Languages(ctx context.Context) ([]moviedb.Language, error)
+	Statistics(ctx context.Context) (*moviedb.Statistics, error)
+
+	// AddMovie adds a new movie, which gets its Id.
+	AddMovie(ctx context.Context, movie *moviedb.Movie) error
+	// SaveMovie saves an existing movie, it never adds one.
+	SaveMovie(ctx context.Context, movie *moviedb.Movie) error
+	DeleteMovie(ctx context.Context, id int) error
+}
+
+// Client requests the moviedb-backend API by URL, responses are JSON, like HTTPClient.
+type Client interface {
+	api.Getter
+	Post(ctx context.Context, url, data string) (string, error)
+	Put(ctx context.Context, url, data string) (string, error)
+	Delete(ctx context.Context, url string) (string, error)
+}
+
+type staleKey struct{}
+
+// WithStale returns a copy of ctx that calls stale whenever a Source reads
+// a stale copy of the data with it, last updated at since.
+func WithStale(ctx context.Context, stale func(since time.Time)) context.Context {
+	return context.WithValue(ctx, staleKey{}, stale)
+}
+
+func markStale(ctx context.Context, since time.Time) {
+	if stale, ok := ctx.Value(staleKey{}).(func(time.Time)); ok {
+		stale(since)
+	}
+}
@@ -0,0 +1,188 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-backend/modules/database"
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DB reads and writes the movie data straight in a moviedb.MovieDB,
+// for a single binary without any backend.
+type DB struct {
+	db moviedb.MovieDB
+}
+
+// NewDB returns the Source of db.
+func NewDB(db moviedb.MovieDB) *DB {
+	return &DB{db}
+}
+
+// OpenSQLite returns the Source of the sqlite database file at path, as created by moviedb-backend.
+func OpenSQLite(path string) (*DB, error) {
+	// sqlite would create a missing file, without any tables
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return NewDB(moviedb.NewMovieDB(&database.Adapter{
+		Database: db,
+		URI:      "sqlite3://" + path,
+		Type:     "sqlite",
+	})), nil
+}
+
+func (d *DB) Movies(ctx context.Context, listing *api.Listing) ([]moviedb.MovieListing, error) {
+	if err := listing.Validate(); err != nil {
+		return nil, err
+	}
+	var options moviedb.MovieListingOptions
+	for _, q := range listing.Queries {
+		options.Query = append(options.Query, moviedb.NewQuery(q.Key, q.Value))
+	}
+	for _, s := range listing.Sorts {
+		options.Sort = append(options.Sort, moviedb.NewSort(s.Field, s.Order))
+	}
+	listings, err := d.db.GetMovieListings(options)
+	if err != nil {
+		return nil, d.fail(err, listing.Path())
+	}
+	movies := make([]moviedb.MovieListing, 0, len(listings))
+	for _, l := range listings {
+		movies = append(movies, *l)
+	}
+	return movies, nil
+}
+
+func (d *DB) Movie(ctx context.Context, id int) (*moviedb.Movie, error) {
+	movie, err := d.db.GetMovie(strconv.Itoa(id))
+	if err != nil {
+		return nil, d.fail(err, fmt.Sprintf("/movie/%d", id))
+	}
+	return movie, nil
+}
+
+func (d *DB) Person(ctx context.Context, id int) (*moviedb.Person, error) {
+	person, err := d.db.GetPerson(strconv.Itoa(id))
+	if err != nil {
+		return nil, d.fail(err, fmt.Sprintf("/person/%d", id))
+	}
+	return person, nil
+}
+
+// Actors returns all actors, the database has no pages.
+func (d *DB) Actors(ctx context.Context, page api.Page) ([]moviedb.Person, error) {
+	actors, err := d.db.GetActors()
+	if err != nil {
+		return nil, d.fail(err, "/actors")
+	}
+	return people(actors), nil
+}
+
+// Directors returns all directors, the database has no pages.
+func (d *DB) Directors(ctx context.Context, page api.Page) ([]moviedb.Person, error) {
+	directors, err := d.db.GetDirectors()
+	if err != nil {
+		return nil, d.fail(err, "/directors")
+	}
+	return people(directors), nil
+}
+
+func (d *DB) Genres(ctx context.Context) ([]moviedb.Genre, error) {
+	genres, err := d.db.GetGenres()
+	if err != nil {
+		return nil, d.fail(err, "/genres")
+	}
+	values := make([]moviedb.Genre, 0, len(genres))
+	for _, g := range genres {
+		values = append(values, *g)
+	}
+	return values, nil
+}
+
+func (d *DB) Languages(ctx context.Context) ([]moviedb.Language, error) {
+	languages, err := d.db.GetLanguages()
+	if err != nil {
+		return nil, d.fail(err, "/languages")
+	}
+	values := make([]moviedb.Language, 0, len(languages))
+	for _, l := range languages {
+		values = append(values, *l)
+	}
+	return values, nil
+}
+
+func (d *DB) Statistics(ctx context.Context) (*moviedb.Statistics, error) {
+	stats, err := d.db.GetStatistics()
+	if err != nil {
+		return nil, d.fail(err, "/statistics")
+	}
+	return stats, nil
+}
+
+func (d *DB) AddMovie(ctx context.Context, movie *moviedb.Movie) error {
+	if err := d.db.AddMovie(movie); err != nil {
+		return d.fail(err, "/movie")
+	}
+	return nil
+}
+
+func (d *DB) SaveMovie(ctx context.Context, movie *moviedb.Movie) error {
+	// SaveMovie would insert a movie that doesn't exist
+	if _, err := d.Movie(ctx, movie.Id); err != nil {
+		return err
+	}
+	if err := d.db.SaveMovie(movie); err != nil {
+		return d.fail(err, fmt.Sprintf("/movie/%d", movie.Id))
+	}
+	return nil
+}
+
+func (d *DB) DeleteMovie(ctx context.Context, id int) error {
+	if _, err := d.Movie(ctx, id); err != nil {
+		return err
+	}
+	if _, err := d.db.DeleteMovie(strconv.Itoa(id)); err != nil {
+		return d.fail(err, fmt.Sprintf("/movie/%d", id))
+	}
+	return nil
+}
+
+// fail classifies err like the backend would respond to a request of path, a missing row is a 404.
+func (d *DB) fail(err error, path string) error {
+	if err == sql.ErrNoRows {
+		return notFound(err)
+	}
+	log.WithFields(logrus.Fields{
+		"error": err,
+		"path":  path,
+	}).Error("Database query failed")
+	return &upstream.Error{Kind: upstream.BadGateway, Err: err}
+}
+
+func notFound(err error) error {
+	return &upstream.Error{Kind: upstream.NotFound, Err: err}
+}
+
+func people(persons []*moviedb.Person) []moviedb.Person {
+	values := make([]moviedb.Person, 0, len(persons))
+	for _, p := range persons {
+		values = append(values, *p)
+	}
+	return values
+}
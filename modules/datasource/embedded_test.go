@@ -0,0 +1,202 @@
+package datasource
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+	"github.com/stretchr/testify/assert"
+)
+
+// schema is the part of the moviedb-backend sqlite schema used by moviedb.MovieDB.
+var schema = []string{
+	`create table movie_movie (id integer primary key, title text, alttitle text, year integer, description text,
+		format text, length integer, disk_region text, rating integer, disks integer, score integer, picture text, disk_type text)`,
+	`create table movie_language (id integer primary key, name text, country text, native_name text)`,
+	`create table movie_genre (id integer primary key, name text)`,
+	`create table movie_people (id integer primary key, name text)`,
+	`create table movie_link_language (movie_id integer, language_id integer)`,
+	`create table movie_link_genre (movie_id integer, genre_id integer)`,
+	`create table movie_link_actor (movie_id integer, person_id integer)`,
+	`create table movie_link_director (movie_id integer, person_id integer)`,
+	`create table movie_dbdate (id integer primary key, date timestamp)`,
+
+	`insert into movie_movie values (1026, 'Army of Darkness', 'Evil Dead 3', 1992, 'Boomstick', 'DVD', 81, '2', 16, 1, 4, 'army_of_darkness.jpg', 'dvd')`,
+	`insert into movie_movie values (98, 'Kill Bill Vol.1', null, 2003, 'Revenge', 'Blu-ray', 111, 'B', 18, 1, 5, 'kill_bill_vol1.jpg', 'bluray')`,
+	`insert into movie_language values (12, 'English', 'United Kingdom', 'English')`,
+	`insert into movie_genre values (23, 'Horror')`,
+	`insert into movie_people values (5027, 'Bruce Campbell')`,
+	`insert into movie_people values (5028, 'Sam Raimi')`,
+	`insert into movie_people values (5211, 'Quentin Tarantino')`,
+	`insert into movie_link_language values (1026, 12)`,
+	`insert into movie_link_genre values (1026, 23)`,
+	`insert into movie_link_actor values (1026, 5027)`,
+	`insert into movie_link_director values (1026, 5028)`,
+	`insert into movie_link_director values (98, 5211)`,
+	`insert into movie_dbdate values (1, '2008-01-01 00:00:00')`,
+	`insert into movie_dbdate values (2, '2015-06-01 00:00:00')`,
+}
+
+func newTestDB(t *testing.T) (*DB, func()) {
+	dir, err := ioutil.TempDir("", "moviedb-datasource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "moviedb.db")
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range schema {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	source, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source, func() { os.RemoveAll(dir) }
+}
+
+func Test_DataSource_Missing(t *testing.T) {
+	_, err := OpenSQLite(filepath.Join(os.TempDir(), "no-such-moviedb.db"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_DataSource_Movies(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	listings, err := db.Movies(ctx, api.Movies())
+	assert.NoError(t, err)
+	if assert.Len(t, listings, 2) {
+		assert.Equal(t, moviedb.MovieListing{Id: 1026, Title: "Army of Darkness", Year: 1992, Score: 4, Rating: 16}, listings[0])
+	}
+
+	listings, err = db.Movies(ctx, api.Movies().Where(api.Director, "5211").OrderBy(api.Year, api.Desc))
+	assert.NoError(t, err)
+	if assert.Len(t, listings, 1) {
+		assert.Equal(t, "Kill Bill Vol.1", listings[0].Title)
+	}
+
+	// the backend would never get unknown keys either
+	_, err = db.Movies(ctx, api.Movies().Where("id;drop", "98"))
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+}
+
+func Test_DataSource_Movie(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	movie, err := db.Movie(ctx, 1026)
+	assert.NoError(t, err)
+	assert.Equal(t, "Army of Darkness", movie.Title)
+	assert.Equal(t, "Evil Dead 3", movie.Alttitle.String)
+	assert.Equal(t, "army_of_darkness.jpg", movie.Picture)
+	if assert.Len(t, movie.Actors, 1) {
+		assert.Equal(t, "Bruce Campbell", movie.Actors[0].Name)
+	}
+	if assert.Len(t, movie.Genres, 1) {
+		assert.Equal(t, 23, movie.Genres[0].Id)
+	}
+
+	_, err = db.Movie(ctx, 1)
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+}
+
+func Test_DataSource_People(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	person, err := db.Person(ctx, 5211)
+	assert.NoError(t, err)
+	assert.Equal(t, &moviedb.Person{Id: 5211, Name: "Quentin Tarantino"}, person)
+
+	actors, err := db.Actors(ctx, api.Page{})
+	assert.NoError(t, err)
+	assert.Equal(t, []moviedb.Person{{Id: 5027, Name: "Bruce Campbell"}}, actors)
+
+	directors, err := db.Directors(ctx, api.Page{Number: 1, PerPage: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []moviedb.Person{{Id: 5211, Name: "Quentin Tarantino"}, {Id: 5028, Name: "Sam Raimi"}}, directors)
+
+	_, err = db.Person(ctx, 1)
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+}
+
+func Test_DataSource_Statistics(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	stats, err := db.Statistics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 192, stats.TotalLength)
+	assert.Equal(t, 2015, stats.LastUpdate.Year())
+
+	genres, err := db.Genres(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []moviedb.Genre{{Id: 23, Name: "Horror"}}, genres)
+
+	languages, err := db.Languages(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []moviedb.Language{{Id: 12, Name: "English", Country: "United Kingdom", NativeName: "English"}}, languages)
+}
+
+func Test_DataSource_Admin(t *testing.T) {
+	db, cleanup := newTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	movie := &moviedb.Movie{
+		Title:  "Evil Dead II",
+		Year:   1987,
+		Score:  5,
+		Type:   "dvd",
+		Genres: []*moviedb.Genre{{Name: "Horror"}, {Name: "Comedy"}},
+		Actors: []*moviedb.Person{{Name: "Bruce Campbell"}},
+	}
+	assert.NoError(t, db.AddMovie(ctx, movie))
+	assert.Equal(t, 1027, movie.Id)
+	// known names keep their Id
+	assert.Equal(t, 23, movie.Genres[0].Id)
+	assert.Equal(t, 24, movie.Genres[1].Id)
+	assert.Equal(t, 5027, movie.Actors[0].Id)
+
+	assert.NoError(t, db.SaveMovie(ctx, &moviedb.Movie{Id: 1027, Title: "Evil Dead 2", Year: 1987, Type: "dvd"}))
+	movie, err := db.Movie(ctx, 1027)
+	assert.NoError(t, err)
+	assert.Equal(t, "Evil Dead 2", movie.Title)
+
+	// saving would insert a missing movie
+	err = db.SaveMovie(ctx, &moviedb.Movie{Id: 2000, Title: "Nope"})
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+	_, err = db.Movie(ctx, 2000)
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+
+	assert.NoError(t, db.DeleteMovie(ctx, 1027))
+	_, err = db.Movie(ctx, 1027)
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+	err = db.DeleteMovie(ctx, 1027)
+	assert.True(t, upstream.Is(err, upstream.NotFound))
+
+	actors, err := db.Actors(ctx, api.Page{})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, actors) {
+		assert.Equal(t, 5027, actors[0].Id)
+	}
+}
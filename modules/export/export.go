@@ -0,0 +1,91 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+)
+
+// Table is a list of rows with a header, ready to be written as CSV or XLSX.
+// Cells are either strings or ints.
+type Table struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// MovieListings returns a Table with the columns of moviedb.MovieListing.
+func MovieListings(listings []moviedb.MovieListing) *Table {
+	t := &Table{
+		Name:   "Movies",
+		Header: []string{"Id", "Title", "Year", "Score", "Rating"},
+	}
+	for _, l := range listings {
+		t.Rows = append(t.Rows, []interface{}{l.Id, l.Title, l.Year, l.Score, l.Rating})
+	}
+	return t
+}
+
+// Movies returns a Table with the columns of moviedb.MovieListing,
+// enriched by the additional fields of moviedb.Movie.
+func Movies(movies []*moviedb.Movie) *Table {
+	t := &Table{
+		Name: "Movies",
+		Header: []string{"Id", "Title", "Year", "Score", "Rating",
+			"Alttitle", "Format", "Type", "Region", "Disks", "Length",
+			"Languages", "Genres", "Actors", "Directors"},
+	}
+	for _, m := range movies {
+		var languages, genres []string
+		for _, l := range m.Languages {
+			languages = append(languages, l.Name)
+		}
+		for _, g := range m.Genres {
+			genres = append(genres, g.Name)
+		}
+		t.Rows = append(t.Rows, []interface{}{m.Id, m.Title, m.Year, m.Score, m.Rating,
+			m.Alttitle.String, m.Format, m.Type, m.Region, m.Disks, m.Length,
+			strings.Join(languages, ", "), strings.Join(genres, ", "),
+			names(m.Actors), names(m.Directors)})
+	}
+	return t
+}
+
+func names(people []*moviedb.Person) string {
+	var names []string
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// text returns cell as text. Titles and descriptions are entered by editors,
+// a text a spreadsheet would run as formula is prefixed with an apostrophe.
+func text(cell interface{}) string {
+	s := fmt.Sprint(cell)
+	if _, ok := cell.(string); ok && len(s) > 0 && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Header); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = text(cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
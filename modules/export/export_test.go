@@ -0,0 +1,107 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Export_CSV(t *testing.T) {
+	table := MovieListings([]moviedb.MovieListing{
+		{Id: 700, Title: "O Brother, Where Art Thou?", Year: 2000, Score: 4, Rating: 12},
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, table.WriteCSV(&buf))
+	assert.Equal(t, "Id,Title,Year,Score,Rating\n700,\"O Brother, Where Art Thou?\",2000,4,12\n", buf.String())
+}
+
+func Test_Export_Formulas(t *testing.T) {
+	table := MovieListings([]moviedb.MovieListing{
+		{Id: 1, Title: "=HYPERLINK(\"http://evil\")", Year: 2000, Score: -1},
+		{Id: 2, Title: "@SUM(A1)"},
+		{Id: 3, Title: "+1"},
+		{Id: 4, Title: "-1"},
+		{Id: 5, Title: "2 + 2 = 4"},
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, table.WriteCSV(&buf))
+	assert.Equal(t, "Id,Title,Year,Score,Rating\n"+
+		"1,\"'=HYPERLINK(\"\"http://evil\"\")\",2000,-1,0\n"+
+		"2,'@SUM(A1),0,0,0\n"+
+		"3,'+1,0,0,0\n"+
+		"4,'-1,0,0,0\n"+
+		"5,2 + 2 = 4,0,0,0\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, table.WriteXLSX(&buf))
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	for _, f := range z.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, err := f.Open()
+		assert.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `<t xml:space="preserve">&#39;@SUM(A1)</t>`)
+	}
+}
+
+func Test_Export_Movies(t *testing.T) {
+	table := Movies([]*moviedb.Movie{{
+		Id:        1026,
+		Title:     "Army of Darkness",
+		Alttitle:  sql.NullString{String: "Evil Dead 3", Valid: true},
+		Year:      1992,
+		Disks:     1,
+		Genres:    []*moviedb.Genre{{Id: 9, Name: "Horror"}, {Id: 3, Name: "Comedy"}},
+		Actors:    []*moviedb.Person{{Id: 1027, Name: "Bruce Campbell"}},
+		Directors: []*moviedb.Person{{Id: 1028, Name: "Sam Raimi"}},
+	}})
+
+	assert.Equal(t, 15, len(table.Header))
+	assert.Equal(t, []interface{}{1026, "Army of Darkness", 1992, 0, 0,
+		"Evil Dead 3", "", "", "", 1, 0, "", "Horror, Comedy", "Bruce Campbell", "Sam Raimi"}, table.Rows[0])
+}
+
+func Test_Export_XLSX(t *testing.T) {
+	table := MovieListings([]moviedb.MovieListing{
+		{Id: 130, Title: "James Bond 007: Dr. No & <friends>", Year: 1962, Score: 4, Rating: 16},
+	})
+
+	var buf bytes.Buffer
+	assert.NoError(t, table.WriteXLSX(&buf))
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+	_, ok := files["[Content_Types].xml"]
+	assert.True(t, ok)
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Movies" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="A1" t="inlineStr"><is><t xml:space="preserve">Id</t></is></c>`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], `<c r="A2"><v>130</v></c><c r="B2" t="inlineStr"><is><t xml:space="preserve">James Bond 007: Dr. No &amp; &lt;friends&gt;</t></is></c>`)
+}
+
+func Test_Export_Column(t *testing.T) {
+	assert.Equal(t, "A", column(0))
+	assert.Equal(t, "Z", column(25))
+	assert.Equal(t, "AA", column(26))
+	assert.Equal(t, "AZ", column(51))
+	assert.Equal(t, "BA", column(52))
+}
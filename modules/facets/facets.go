@@ -0,0 +1,294 @@
+package facets
+
+import (
+	"encoding/xml"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/negotiation"
+)
+
+// attribute is a movie attribute to filter by, named like its backend query.
+// The format query is renamed to picture_format, format is taken by content negotiation.
+type attribute struct {
+	name    string
+	title   string
+	all     bool // movies must have all selected values, instead of any of them
+	numeric bool // values are sorted as numbers
+	values  func(m *moviedb.Movie) []option
+}
+
+type option struct {
+	value, label string
+}
+
+// rangeAttribute is a numeric movie attribute to filter by a from/to range.
+type rangeAttribute struct {
+	name  string
+	title string
+	value func(m *moviedb.Movie) int
+}
+
+var attributes = []attribute{
+	{"genre", "Genre", true, false, func(m *moviedb.Movie) []option {
+		options := make([]option, 0, len(m.Genres))
+		for _, g := range m.Genres {
+			options = append(options, option{strconv.Itoa(g.Id), g.Name})
+		}
+		return options
+	}},
+	{"language", "Language", true, false, func(m *moviedb.Movie) []option {
+		options := make([]option, 0, len(m.Languages))
+		for _, l := range m.Languages {
+			options = append(options, option{strconv.Itoa(l.Id), l.Name})
+		}
+		return options
+	}},
+	{"score", "Score", false, true, func(m *moviedb.Movie) []option {
+		if m.Score == 0 {
+			return nil
+		}
+		return []option{{strconv.Itoa(m.Score), strings.Repeat("✰", m.Score)}}
+	}},
+	{"rating", "Rating", false, true, func(m *moviedb.Movie) []option {
+		return []option{{strconv.Itoa(m.Rating), strconv.Itoa(m.Rating)}}
+	}},
+	{"picture_format", "Format", false, false, func(m *moviedb.Movie) []option {
+		return []option{{m.Format, m.Format}}
+	}},
+	{"disk_type", "Disk Type", false, false, func(m *moviedb.Movie) []option {
+		return []option{{m.Type, m.Type}}
+	}},
+	{"disk_region", "Region", false, false, func(m *moviedb.Movie) []option {
+		return []option{{m.Region, m.Region}}
+	}},
+}
+
+var ranges = []rangeAttribute{
+	{"year", "Year", func(m *moviedb.Movie) int { return m.Year }},
+	{"length", "Runtime (min)", func(m *moviedb.Movie) int { return m.Length }},
+}
+
+// Selection holds the selected facet values and ranges of a browse request.
+type Selection struct {
+	values map[string][]string
+	from   map[string]int
+	to     map[string]int
+}
+
+// Parse reads the selection from query parameters like genre=9&genre=3&year_from=1990.
+func Parse(query url.Values) *Selection {
+	s := &Selection{
+		values: make(map[string][]string),
+		from:   make(map[string]int),
+		to:     make(map[string]int),
+	}
+	for _, a := range attributes {
+		for _, value := range query[a.name] {
+			if len(value) > 0 {
+				s.values[a.name] = append(s.values[a.name], value)
+			}
+		}
+	}
+	for _, r := range ranges {
+		if from, err := strconv.Atoi(query.Get(r.name + "_from")); err == nil && from > 0 {
+			s.from[r.name] = from
+		}
+		if to, err := strconv.Atoi(query.Get(r.name + "_to")); err == nil && to > 0 {
+			s.to[r.name] = to
+		}
+	}
+	return s
+}
+
+// BackendQuery returns the query/value pairs of the selection the backend can filter by itself.
+// Those are the first value of every facet movies must have all values of, and exact years.
+// Everything else is filtered by Apply.
+func (s *Selection) BackendQuery() []api.Query {
+	var queries []api.Query
+	for _, a := range attributes {
+		if values := s.values[a.name]; a.all && len(values) > 0 {
+			queries = append(queries, api.Query{Key: a.name, Value: values[0]})
+		}
+	}
+	if from, ok := s.from["year"]; ok && from == s.to["year"] {
+		queries = append(queries, api.Query{Key: api.Year, Value: strconv.Itoa(from)})
+	}
+	return queries
+}
+
+// matches checks whether m is selected, ignoring the facet named except.
+func (s *Selection) matches(m *moviedb.Movie, except string) bool {
+	for _, a := range attributes {
+		selected := s.values[a.name]
+		if a.name == except || len(selected) == 0 {
+			continue
+		}
+		found := 0
+		for _, value := range selected {
+			for _, o := range a.values(m) {
+				if o.value == value {
+					found++
+					break
+				}
+			}
+		}
+		if found == 0 || (a.all && found < len(selected)) {
+			return false
+		}
+	}
+	for _, r := range ranges {
+		if r.name == except {
+			continue
+		}
+		value := r.value(m)
+		if from, ok := s.from[r.name]; ok && value < from {
+			return false
+		}
+		if to, ok := s.to[r.name]; ok && value > to {
+			return false
+		}
+	}
+	return true
+}
+
+// Facet is a movie attribute with all its values found and how many movies have them.
+type Facet struct {
+	Name   string  `json:"name" xml:"name,attr"`
+	Title  string  `json:"title" xml:"title,attr"`
+	Values []Value `json:"values" xml:"value"`
+}
+
+// Value is a single facet value, Count is the number of movies found by selecting it.
+type Value struct {
+	Value    string `json:"value" xml:"id,attr"`
+	Label    string `json:"label" xml:",chardata"`
+	Count    int    `json:"count" xml:"count,attr"`
+	Selected bool   `json:"selected" xml:"selected,attr"`
+}
+
+// Range is a numeric movie attribute with the bounds found, and the ones selected.
+type Range struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Title string `json:"title" xml:"title,attr"`
+	Min   int    `json:"min" xml:"min,attr"`
+	Max   int    `json:"max" xml:"max,attr"`
+	From  int    `json:"from,omitempty" xml:"from,attr,omitempty"`
+	To    int    `json:"to,omitempty" xml:"to,attr,omitempty"`
+}
+
+// Result is the content of the browse page.
+type Result struct {
+	Facets []*Facet               `json:"facets"`
+	Ranges []*Range               `json:"ranges"`
+	Movies []moviedb.MovieListing `json:"movies"`
+	Limit  int                    `json:"limit,omitempty"` // only that many movies were browsed, 0 if all of them
+}
+
+func (r *Result) XML() interface{} {
+	return &struct {
+		XMLName xml.Name                   `xml:"browse"`
+		Limit   int                        `xml:"limit,attr,omitempty"`
+		Facets  []*Facet                   `xml:"facets>facet"`
+		Ranges  []*Range                   `xml:"ranges>range"`
+		Movies  []negotiation.MovieListing `xml:"movies>movie"`
+	}{
+		Limit:  r.Limit,
+		Facets: r.Facets,
+		Ranges: r.Ranges,
+		Movies: negotiation.MovieListings(r.Movies),
+	}
+}
+
+// Apply filters movies by the selection and counts the facet values.
+// Values of facets that need all of them to match are counted among the selected movies,
+// the others among the movies matching all other facets, i.e. what a click would add.
+func (s *Selection) Apply(movies []*moviedb.Movie) *Result {
+	result := &Result{
+		Movies: make([]moviedb.MovieListing, 0),
+	}
+	for _, m := range movies {
+		if s.matches(m, "") {
+			result.Movies = append(result.Movies, moviedb.MovieListing{
+				Id:     m.Id,
+				Title:  m.Title,
+				Year:   m.Year,
+				Score:  m.Score,
+				Rating: m.Rating,
+			})
+		}
+	}
+
+	for _, a := range attributes {
+		except := a.name
+		if a.all {
+			except = ""
+		}
+		counts := make(map[string]*Value)
+		for _, value := range s.values[a.name] {
+			counts[value] = &Value{Value: value, Label: value, Selected: true}
+		}
+		for _, m := range movies {
+			if !s.matches(m, except) {
+				continue
+			}
+			for _, o := range a.values(m) {
+				if len(o.value) == 0 {
+					continue
+				}
+				value, ok := counts[o.value]
+				if !ok {
+					value = &Value{Value: o.value}
+					counts[o.value] = value
+				}
+				value.Label = o.label
+				value.Count++
+			}
+		}
+
+		facet := &Facet{Name: a.name, Title: a.title, Values: make([]Value, 0, len(counts))}
+		for _, value := range counts {
+			facet.Values = append(facet.Values, *value)
+		}
+		sort.Sort(&byValue{facet.Values, a.numeric})
+		result.Facets = append(result.Facets, facet)
+	}
+
+	for _, r := range ranges {
+		rng := &Range{Name: r.name, Title: r.title, From: s.from[r.name], To: s.to[r.name]}
+		for _, m := range movies {
+			value := r.value(m)
+			if value <= 0 || !s.matches(m, r.name) {
+				continue
+			}
+			if rng.Min == 0 || value < rng.Min {
+				rng.Min = value
+			}
+			if value > rng.Max {
+				rng.Max = value
+			}
+		}
+		result.Ranges = append(result.Ranges, rng)
+	}
+	return result
+}
+
+type byValue struct {
+	values  []Value
+	numeric bool
+}
+
+func (v *byValue) Len() int      { return len(v.values) }
+func (v *byValue) Swap(i, j int) { v.values[i], v.values[j] = v.values[j], v.values[i] }
+func (v *byValue) Less(i, j int) bool {
+	if v.numeric {
+		a, _ := strconv.Atoi(v.values[i].Value)
+		b, _ := strconv.Atoi(v.values[j].Value)
+		return a < b
+	}
+	return strings.ToLower(v.values[i].Label) < strings.ToLower(v.values[j].Label)
+}
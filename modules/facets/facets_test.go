@@ -0,0 +1,53 @@
+package facets
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var movies = []*moviedb.Movie{
+	{Id: 51, Title: "From Dusk Till Dawn", Year: 1996, Score: 3, Length: 104, Format: "16:9",
+		Genres: []*moviedb.Genre{{Id: 23, Name: "Crime"}, {Id: 9, Name: "Horror"}}},
+	{Id: 98, Title: "Kill Bill Vol.1", Year: 2003, Score: 4, Length: 107, Format: "16:9",
+		Genres: []*moviedb.Genre{{Id: 1, Name: "Action"}, {Id: 23, Name: "Crime"}}},
+	{Id: 200, Title: "Heat", Year: 1995, Score: 5, Length: 170, Format: "4:3",
+		Genres: []*moviedb.Genre{{Id: 23, Name: "Crime"}, {Id: 7, Name: "Drama"}}},
+}
+
+func parse(t *testing.T, query string) *Selection {
+	values, err := url.ParseQuery(query)
+	assert.NoError(t, err)
+	return Parse(values)
+}
+
+func Test_Facets_BackendQuery(t *testing.T) {
+	assert.Nil(t, parse(t, "score=4&score=5&year_from=1990").BackendQuery())
+	assert.Equal(t, []api.Query{{Key: "genre", Value: "23"}, {Key: "language", Value: "2"}},
+		parse(t, "genre=23&genre=9&language=2&picture_format=16:9").BackendQuery())
+	assert.Equal(t, []api.Query{{Key: "year", Value: "1996"}}, parse(t, "year_from=1996&year_to=1996").BackendQuery())
+}
+
+func Test_Facets_Apply(t *testing.T) {
+	result := parse(t, "genre=23&genre=9").Apply(movies)
+	assert.Equal(t, 1, len(result.Movies))
+	assert.Equal(t, 51, result.Movies[0].Id)
+	assert.Equal(t, []Value{
+		{Value: "23", Label: "Crime", Count: 1, Selected: true},
+		{Value: "9", Label: "Horror", Count: 1, Selected: true},
+	}, result.Facets[0].Values)
+
+	result = parse(t, "score=4&score=5&length_from=100&length_to=120").Apply(movies)
+	assert.Equal(t, 1, len(result.Movies))
+	assert.Equal(t, 98, result.Movies[0].Id)
+	assert.Equal(t, "score", result.Facets[2].Name)
+	assert.Equal(t, []Value{
+		{Value: "3", Label: "✰✰✰", Count: 1},
+		{Value: "4", Label: "✰✰✰✰", Count: 1, Selected: true},
+		{Value: "5", Label: "5", Count: 0, Selected: true}, // Heat is too long
+	}, result.Facets[2].Values)
+	assert.Equal(t, &Range{Name: "length", Title: "Runtime (min)", Min: 107, Max: 170, From: 100, To: 120}, result.Ranges[1])
+}
@@ -0,0 +1,118 @@
+package fallback
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+var (
+	log *logrus.Logger
+)
+
+func init() {
+	log = logger.GetLogger()
+}
+
+// StaleError is returned by Fallback.Get if the backend failed,
+// but a last-known-good response could be served instead.
+type StaleError struct {
+	Record *Record
+	Err    error
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf("serving stale response of %s from %v: %v", e.Record.URL, e.Record.Updated, e.Err)
+}
+
+// Fallback keeps a last-known-good copy of every successful backend response
+// and serves it whenever the backend fails.
+type Fallback struct {
+	getter   api.Getter
+	store    *Store
+	mutex    sync.RWMutex
+	degraded bool
+	since    time.Time
+}
+
+// New returns a Fallback persisting at most size of its responses to dir.
+func New(getter api.Getter, dir string, size int) *Fallback {
+	store, err := NewStore(dir, size)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"dir":   dir,
+		}).Error("Could not create fallback store, degraded mode is disabled")
+	}
+	return NewFallback(getter, store)
+}
+
+// NewFallback returns a Fallback using store, which may be nil to disable it.
+func NewFallback(getter api.Getter, store *Store) *Fallback {
+	return &Fallback{
+		getter: getter,
+		store:  store,
+	}
+}
+
+// Get returns the backend response for url. If the backend fails and there is
+// a last-known-good response, that is returned wrapped in a *StaleError.
+// Resources the backend does not know are never served stale.
+func (f *Fallback) Get(ctx context.Context, url string) (string, error) {
+	response, err := f.getter.Get(ctx, url)
+	if upstream.Is(err, upstream.NotFound) {
+		// a valid answer of a working backend, there is nothing to fall back to
+		f.setDegraded(false)
+		return "", err
+	}
+	if err != nil && ctx.Err() != nil {
+		// given up by the caller, which tells nothing about the backend
+		return "", err
+	}
+	if err != nil {
+		f.setDegraded(true)
+		if f.store == nil {
+			return "", err
+		}
+		record, storeErr := f.store.Get(url)
+		if storeErr != nil {
+			return "", err
+		}
+		return "", &StaleError{record, err}
+	}
+	f.setDegraded(false)
+
+	if f.store != nil {
+		if err := f.store.Put(url, response); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"url":   url,
+			}).Warn("Could not store backend response")
+		}
+	}
+	return response, nil
+}
+
+// Degraded reports whether the last backend request failed, and since when.
+func (f *Fallback) Degraded() (bool, time.Time) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.degraded, f.since
+}
+
+func (f *Fallback) setDegraded(degraded bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if degraded && !f.degraded {
+		f.since = time.Now()
+	}
+	f.degraded = degraded
+}
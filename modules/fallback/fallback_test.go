@@ -0,0 +1,206 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/upstream"
+	"github.com/stretchr/testify/assert"
+)
+
+type getter struct {
+	response string
+	err      error
+}
+
+func (g *getter) Get(ctx context.Context, url string) (string, error) {
+	return g.response, g.err
+}
+
+func Test_Fallback_Stale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 0)
+	assert.NoError(t, err)
+
+	g := &getter{response: `{"id":1}`}
+	f := NewFallback(g, store)
+
+	response, err := f.Get(context.Background(), "/movie/1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, response)
+	degraded, _ := f.Degraded()
+	assert.False(t, degraded)
+
+	g.err = errors.New("backend down")
+
+	// survives restarts
+	store, err = NewStore(dir, 0)
+	assert.NoError(t, err)
+	f = NewFallback(g, store)
+
+	_, err = f.Get(context.Background(), "/movie/1")
+	if assert.IsType(t, &StaleError{}, err) {
+		stale := err.(*StaleError)
+		assert.Equal(t, `{"id":1}`, stale.Record.Response)
+		assert.Equal(t, "/movie/1", stale.Record.URL)
+		assert.Equal(t, g.err, stale.Err)
+	}
+	degraded, _ = f.Degraded()
+	assert.True(t, degraded)
+
+	_, err = f.Get(context.Background(), "/movie/2")
+	assert.Equal(t, g.err, err)
+
+	g.err = nil
+	f.Get(context.Background(), "/movie/2")
+	degraded, _ = f.Degraded()
+	assert.False(t, degraded)
+}
+
+func Test_Fallback_NoStore(t *testing.T) {
+	g := &getter{err: errors.New("backend down")}
+	f := NewFallback(g, nil)
+
+	_, err := f.Get(context.Background(), "/movies")
+	assert.Equal(t, g.err, err)
+}
+
+func Test_Fallback_Canceled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 0)
+	assert.NoError(t, err)
+
+	g := &getter{response: `{"id":1}`}
+	f := NewFallback(g, store)
+	f.Get(context.Background(), "/movie/1")
+
+	// nobody waits for the response anymore, the backend may be fine
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.err = ctx.Err()
+	_, err = f.Get(ctx, "/movie/1")
+	assert.Equal(t, context.Canceled, err)
+	degraded, _ := f.Degraded()
+	assert.False(t, degraded)
+}
+
+func Test_Fallback_NotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 0)
+	assert.NoError(t, err)
+
+	g := &getter{response: `{"id":1}`}
+	f := NewFallback(g, store)
+	f.Get(context.Background(), "/movie/1")
+
+	// deleted from the backend in the meantime
+	g.err = &upstream.Error{Kind: upstream.NotFound, Status: 404}
+	_, err = f.Get(context.Background(), "/movie/1")
+	assert.Equal(t, g.err, err)
+	degraded, _ := f.Degraded()
+	assert.False(t, degraded)
+}
+
+func Test_Fallback_StoreSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 10)
+	assert.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		url := fmt.Sprintf("/movie/%d", i)
+		assert.NoError(t, store.Put(url, "{}"))
+		updated := past.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(store.filename(url), updated, updated))
+	}
+	// updating a record doesn't add one
+	assert.NoError(t, store.Put("/movie/9", "{}"))
+	_, err = store.Get("/movie/0")
+	assert.NoError(t, err)
+
+	// the least recently updated ones are evicted
+	assert.NoError(t, store.Put("/movie/10", "{}"))
+	for i, kept := range []bool{false, false, true, true, true, true, true, true, true, true, true} {
+		_, err := store.Get(fmt.Sprintf("/movie/%d", i))
+		assert.Equal(t, kept, err == nil, i)
+	}
+
+	// also on restart with a smaller size
+	store, err = NewStore(dir, 5)
+	assert.NoError(t, err)
+	files, err := records(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(files))
+}
+
+func Test_Fallback_StoreUnchanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Put("/genres", "[]"))
+	first, err := store.Get("/genres")
+	assert.NoError(t, err)
+
+	// an unchanged response is not written again
+	assert.NoError(t, store.Put("/genres", "[]"))
+	record, err := store.Get("/genres")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Updated, record.Updated)
+
+	// unless the record is getting old
+	filename := store.filename("/genres")
+	last := store.written[filename]
+	last.updated = last.updated.Add(-rewrite)
+	store.written[filename] = last
+	assert.NoError(t, store.Put("/genres", "[]"))
+	record, err = store.Get("/genres")
+	assert.NoError(t, err)
+	assert.True(t, record.Updated.After(first.Updated))
+
+	assert.NoError(t, store.Put("/genres", `[{"id":1}]`))
+	record, err = store.Get("/genres")
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"id":1}]`, record.Response)
+}
+
+func Test_Fallback_StoreConcurrentPut(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fallback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewStore(dir, 10)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, store.Put("/statistics", fmt.Sprintf(`{"movies":%d}`, i)))
+		}(i)
+	}
+	wg.Wait()
+	// the same new record, put at once, is only counted once
+	assert.Equal(t, 1, store.count)
+}
@@ -0,0 +1,174 @@
+package fallback
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a backend response persisted by the Store.
+type Record struct {
+	URL      string    `json:"url"`
+	Response string    `json:"response"`
+	Updated  time.Time `json:"updated"`
+}
+
+// rewrite is how long an unchanged response is not written again, which makes
+// the Updated time of a record accurate to that.
+const rewrite = time.Minute
+
+// Store persists backend responses as JSON files inside a directory,
+// so they survive restarts. It keeps at most size records, a size of 0 means no limit.
+type Store struct {
+	dir   string
+	size  int
+	mutex sync.Mutex // held while writing records
+	count int
+	// written is what the store wrote to each file since it was opened
+	written map[string]written
+}
+
+type written struct {
+	hash    [sha256.Size]byte
+	updated time.Time
+}
+
+// NewStore returns the Store of dir, evicting the records beyond size right away.
+func NewStore(dir string, size int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	files, err := records(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, size: size, count: len(files), written: make(map[string]written)}
+	if err := s.evict(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put stores response as the record of url. It is not written again if it didn't change
+// during the last minute, every request would have to wait for that otherwise.
+func (s *Store) Put(url, response string) error {
+	filename := s.filename(url)
+	hash := sha256.Sum256([]byte(response))
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	last, known := s.written[filename]
+	if known && last.hash == hash && now.Sub(last.updated) < rewrite {
+		return nil
+	}
+	added := false
+	if !known {
+		_, err := os.Stat(filename)
+		added = os.IsNotExist(err)
+	}
+
+	data, err := json.Marshal(&Record{
+		URL:      url,
+		Response: response,
+		Updated:  now,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.write(filename, data); err != nil {
+		return err
+	}
+	s.written[filename] = written{hash: hash, updated: now}
+	if !added {
+		return nil
+	}
+	s.count++
+	return s.evict()
+}
+
+// write writes data to a temporary file first, so readers never see partial records.
+func (s *Store) write(filename string, data []byte) error {
+	tmp, err := ioutil.TempFile(s.dir, ".record")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Get returns the record of url, an error if there is none.
+func (s *Store) Get(url string) (*Record, error) {
+	data, err := ioutil.ReadFile(s.filename(url))
+	if err != nil {
+		return nil, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *Store) filename(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// evict removes the least recently updated records once there are more than size of them,
+// down to nine tenths of size, so not every Put has to look at all records.
+func (s *Store) evict() error {
+	if s.size <= 0 || s.count <= s.size {
+		return nil
+	}
+	files, err := records(s.dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	keep := s.size * 9 / 10
+	for len(files) > keep {
+		filename := filepath.Join(s.dir, files[0].Name())
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		delete(s.written, filename)
+		files = files[1:]
+	}
+	s.count = len(files)
+	return nil
+}
+
+// records returns the record files in dir.
+func records(dir string) ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var records []os.FileInfo
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			records = append(records, f)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,136 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Call is a single backend call of a fan-out, it should give up once ctx is done.
+type Call func(ctx context.Context) (interface{}, error)
+
+// Fanout runs independent calls concurrently, sharing a deadline.
+// Calls still running when Wait returns are abandoned and their context is cancelled.
+type Fanout struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	names  []string
+	done   chan struct{}
+	result chan result
+}
+
+type result struct {
+	name  string
+	value interface{}
+	err   error
+}
+
+// New returns a Fanout whose calls are cancelled along with parent, or after timeout.
+func New(parent context.Context, timeout time.Duration) *Fanout {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return &Fanout{
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		result: make(chan result),
+	}
+}
+
+// Go starts fn as the call named name. Names must be unique within a Fanout.
+func (f *Fanout) Go(name string, fn Call) {
+	f.names = append(f.names, name)
+	go func() {
+		r := result{name: name}
+		defer func() {
+			if p := recover(); p != nil {
+				r.value, r.err = nil, fmt.Errorf("panic: %v", p)
+			}
+			select {
+			case f.result <- r:
+			case <-f.done:
+			}
+		}()
+		r.value, r.err = fn(f.ctx)
+	}()
+}
+
+// Wait waits for all calls to finish, or the deadline to pass, and returns their results.
+// Calls that did not finish in time fail with the error of the context.
+func (f *Fanout) Wait() *Results {
+	defer f.cancel()
+	defer close(f.done)
+
+	results := &Results{
+		names:  f.names,
+		values: make(map[string]interface{}),
+		errors: make(Errors),
+	}
+	for pending := len(f.names); pending > 0; pending-- {
+		select {
+		case r := <-f.result:
+			if r.err != nil {
+				results.errors[r.name] = r.err
+			} else {
+				results.values[r.name] = r.value
+			}
+		case <-f.ctx.Done():
+			for _, name := range f.names {
+				if _, ok := results.values[name]; !ok && results.errors[name] == nil {
+					results.errors[name] = f.ctx.Err()
+				}
+			}
+			return results
+		}
+	}
+	return results
+}
+
+// Results holds the values of the successful calls of a Fanout, and the errors of the others.
+type Results struct {
+	names  []string
+	values map[string]interface{}
+	errors Errors
+}
+
+// Value returns the value of the call named name, nil if it failed.
+func (r *Results) Value(name string) interface{} {
+	return r.values[name]
+}
+
+// Failed returns the names of the failed calls, in the order they were started.
+func (r *Results) Failed() []string {
+	failed := make([]string, 0, len(r.errors))
+	for _, name := range r.names {
+		if r.errors[name] != nil {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// Failure returns the error of the call named name, nil if it succeeded.
+func (r *Results) Failure(name string) error {
+	return r.errors[name]
+}
+
+// Err returns the errors of all failed calls, or nil if all of them succeeded.
+func (r *Results) Err() error {
+	if len(r.errors) == 0 {
+		return nil
+	}
+	return r.errors
+}
+
+// Errors are the errors of the failed calls of a Fanout, by name.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for name, err := range e {
+		messages = append(messages, fmt.Sprintf("%s: %v", name, err))
+	}
+	sort.Strings(messages)
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,85 @@
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func value(v interface{}, delay time.Duration) Call {
+	return func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-time.After(delay):
+			return v, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func Test_Fanout_Concurrent(t *testing.T) {
+	f := New(context.Background(), time.Second)
+	f.Go("a", value("A", 50*time.Millisecond))
+	f.Go("b", value("B", 50*time.Millisecond))
+	f.Go("c", value("C", 50*time.Millisecond))
+
+	start := time.Now()
+	results := f.Wait()
+	assert.True(t, time.Since(start) < 140*time.Millisecond)
+
+	assert.NoError(t, results.Err())
+	assert.Empty(t, results.Failed())
+	assert.Equal(t, "A", results.Value("a"))
+	assert.Equal(t, "B", results.Value("b"))
+	assert.Equal(t, "C", results.Value("c"))
+}
+
+func Test_Fanout_PartialFailure(t *testing.T) {
+	f := New(context.Background(), time.Second)
+	f.Go("a", value("A", 0))
+	f.Go("b", func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	f.Go("c", func(ctx context.Context) (interface{}, error) {
+		panic("oops")
+	})
+
+	results := f.Wait()
+	assert.Equal(t, "A", results.Value("a"))
+	assert.Nil(t, results.Value("b"))
+	assert.Equal(t, []string{"b", "c"}, results.Failed())
+	assert.EqualError(t, results.Err(), "b: boom; c: panic: oops")
+}
+
+func Test_Fanout_Deadline(t *testing.T) {
+	f := New(context.Background(), 50*time.Millisecond)
+	f.Go("fast", value("fast", 0))
+	f.Go("slow", value("slow", time.Second))
+	f.Go("stuck", func(ctx context.Context) (interface{}, error) {
+		// ignores its context, is abandoned
+		time.Sleep(200 * time.Millisecond)
+		return "stuck", nil
+	})
+
+	start := time.Now()
+	results := f.Wait()
+	assert.True(t, time.Since(start) < 150*time.Millisecond)
+
+	assert.Equal(t, "fast", results.Value("fast"))
+	assert.Equal(t, []string{"slow", "stuck"}, results.Failed())
+	assert.Equal(t, context.DeadlineExceeded, results.Err().(Errors)["stuck"])
+}
+
+func Test_Fanout_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := New(ctx, time.Second)
+	f.Go("slow", value("slow", time.Second))
+	cancel()
+
+	results := f.Wait()
+	assert.Equal(t, []string{"slow"}, results.Failed())
+	assert.Equal(t, context.Canceled, results.Err().(Errors)["slow"])
+}
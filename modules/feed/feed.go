@@ -0,0 +1,168 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+// Feed is the format independent representation of a news feed.
+type Feed struct {
+	Title   string
+	Link    string // alternate HTML page
+	Self    string // URL of the feed itself
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is a single item of a Feed.
+type Entry struct {
+	ID      string // permanent, unlike the Link; defaults to the Link
+	Title   string
+	Link    string
+	Summary string
+	Image   string
+	Updated time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Atom returns the feed as Atom 1.0 document.
+func (f *Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		Title:   f.Title,
+		ID:      f.Self,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, e := range f.Entries {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   e.Title,
+			ID:      e.id(),
+			Updated: e.Updated.UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: e.Link, Rel: "alternate", Type: "text/html"}},
+			Content: atomContent{Type: "html", Body: e.html()},
+		})
+	}
+	return marshal(feed)
+}
+
+// RSS returns the feed as RSS 2.0 document.
+func (f *Feed) RSS() ([]byte, error) {
+	feed := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Self:          atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, e := range f.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        e.id(),
+			PubDate:     e.Updated.UTC().Format(time.RFC1123Z),
+			Description: e.html(),
+		}
+		if len(e.Image) > 0 {
+			item.Enclosure = &rssEnclosure{URL: e.Image, Type: "image/jpeg"}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return marshal(feed)
+}
+
+// id returns the ID of the entry, or its Link if it has none.
+func (e *Entry) id() string {
+	if len(e.ID) > 0 {
+		return e.ID
+	}
+	return e.Link
+}
+
+// html returns the entry summary as HTML, with the image on top.
+// The summary is plain text like moviedb.Movie.Description, which editors can change, so it is escaped.
+func (e *Entry) html() string {
+	if len(e.Image) == 0 {
+		return xmlEscape(e.Summary)
+	}
+	return `<p><img src="` + xmlEscape(e.Image) + `" alt="` + xmlEscape(e.Title) + `"></p><p>` + xmlEscape(e.Summary) + `</p>`
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,61 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFeed = &Feed{
+	Title:   "jamesclonk.io - Movie Database",
+	Link:    "http://moviedb.jamesclonk.io/movies",
+	Self:    "http://moviedb.jamesclonk.io/feed.atom",
+	Updated: time.Date(2015, 6, 14, 0, 0, 0, 0, time.UTC),
+	Entries: []Entry{{
+		ID:      "http://moviedb.jamesclonk.io/movie/1026",
+		Title:   "Army of Darkness",
+		Link:    "http://moviedb.jamesclonk.io/movie/1026-army-of-darkness",
+		Summary: "A man is accidentally transported to 1300 A.D.",
+		Image:   "http://moviedb.jamesclonk.io/images/movies/army_of_darkness.jpg",
+		Updated: time.Date(2015, 6, 14, 0, 0, 0, 0, time.UTC),
+	}},
+}
+
+func Test_Feed_Atom(t *testing.T) {
+	data, err := testFeed.Atom()
+	assert.NoError(t, err)
+
+	atom := string(data)
+	assert.Contains(t, atom, `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, atom, `<link href="http://moviedb.jamesclonk.io/feed.atom" rel="self" type="application/atom+xml"></link>`)
+	assert.Contains(t, atom, `<updated>2015-06-14T00:00:00Z</updated>`)
+	assert.Contains(t, atom, `<id>http://moviedb.jamesclonk.io/movie/1026</id>`)
+	assert.Contains(t, atom, `<link href="http://moviedb.jamesclonk.io/movie/1026-army-of-darkness" rel="alternate" type="text/html"></link>`)
+	assert.Contains(t, atom, `<content type="html">&lt;p&gt;&lt;img src=&#34;http://moviedb.jamesclonk.io/images/movies/army_of_darkness.jpg&#34; alt=&#34;Army of Darkness&#34;&gt;&lt;/p&gt;&lt;p&gt;A man is accidentally transported to 1300 A.D.&lt;/p&gt;</content>`)
+}
+
+func Test_Feed_RSS(t *testing.T) {
+	data, err := testFeed.RSS()
+	assert.NoError(t, err)
+
+	rss := string(data)
+	assert.Contains(t, rss, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, rss, `<atom:link href="http://moviedb.jamesclonk.io/feed.atom" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, rss, `<lastBuildDate>Sun, 14 Jun 2015 00:00:00 +0000</lastBuildDate>`)
+	assert.Contains(t, rss, `<link>http://moviedb.jamesclonk.io/movie/1026-army-of-darkness</link>`)
+	assert.Contains(t, rss, `<guid>http://moviedb.jamesclonk.io/movie/1026</guid>`)
+	assert.Contains(t, rss, `<enclosure url="http://moviedb.jamesclonk.io/images/movies/army_of_darkness.jpg" length="0" type="image/jpeg"></enclosure>`)
+}
+
+func Test_Feed_EscapedSummary(t *testing.T) {
+	f := &Feed{Entries: []Entry{{Title: "Evil Dead", Summary: `<script>alert("boo")</script> & more`}}}
+	data, err := f.Atom()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `<content type="html">&amp;lt;script&amp;gt;alert(&amp;#34;boo&amp;#34;)&amp;lt;/script&amp;gt; &amp;amp; more</content>`)
+	assert.NotContains(t, string(data), `&lt;script&gt;`)
+
+	data, err = f.RSS()
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `<description>&amp;lt;script&amp;gt;alert(&amp;#34;boo&amp;#34;)&amp;lt;/script&amp;gt; &amp;amp; more</description>`)
+}
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+const (
+	OK   = "ok"
+	Fail = "fail"
+)
+
+// Check returns an error if whatever it checks is not ready.
+type Check func() error
+
+// Result is the outcome of a single Check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of all checks of a Checker.
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// Checker runs named readiness checks concurrently, each within a timeout.
+type Checker struct {
+	timeout time.Duration
+	mutex   sync.Mutex
+	checks  map[string]Check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Add registers check under name, replacing any check of the same name.
+func (c *Checker) Add(name string, check Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks[name] = check
+}
+
+// Run runs all checks and reports whether all of them passed.
+func (c *Checker) Run() (*Report, bool) {
+	c.mutex.Lock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mutex.Unlock()
+
+	results := make([]*Result, len(names))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.run(checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := &Report{Status: OK, Checks: make(map[string]*Result)}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != OK {
+			report.Status = Fail
+		}
+	}
+	return report, report.Status == OK
+}
+
+func (c *Checker) run(check Check) *Result {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(c.timeout):
+		err = fmt.Errorf("timed out after %v", c.timeout)
+	}
+
+	result := &Result{Status: OK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = Fail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Handler writes the report of all checks as JSON, with status 503 if any of them failed.
+func (c *Checker) Handler(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report, ok := c.Run()
+		status := http.StatusOK
+		if !ok {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		f.Render.JSON(w, status, report)
+	}
+}
+
+// Liveness writes a static OK, for as long as the process is able to serve requests at all.
+func Liveness(f *web.Frontend) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		f.Render.JSON(w, http.StatusOK, map[string]string{"status": OK})
+	}
+}
+
+// Template returns a Check rendering the named template of f, which fails
+// if the templates could not be loaded.
+func Template(f *web.Frontend, name string) Check {
+	return func() error {
+		w := &recorder{header: make(http.Header), status: http.StatusOK}
+		f.Render.HTML(w, http.StatusOK, name, &web.Page{Title: f.PageMaster.Title, Template: name})
+		if w.status != http.StatusOK {
+			return fmt.Errorf("could not render template %s: %s", name, bytes.TrimSpace(w.body.Bytes()))
+		}
+		return nil
+	}
+}
+
+// recorder is a http.ResponseWriter keeping everything in memory.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Health_Run(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Add("backend", func() error { return nil })
+	checker.Add("templates", func() error { return nil })
+
+	report, ok := checker.Run()
+	assert.True(t, ok)
+	assert.Equal(t, OK, report.Status)
+	assert.Equal(t, 2, len(report.Checks))
+	assert.Equal(t, OK, report.Checks["backend"].Status)
+	assert.Equal(t, "", report.Checks["backend"].Error)
+}
+
+func Test_Health_Fail(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Add("backend", func() error { return errors.New("connection refused") })
+	checker.Add("slow", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	checker.Add("templates", func() error { return nil })
+
+	report, ok := checker.Run()
+	assert.False(t, ok)
+	assert.Equal(t, Fail, report.Status)
+	assert.Equal(t, &Result{Status: Fail, Error: "connection refused", Duration: report.Checks["backend"].Duration}, report.Checks["backend"])
+	assert.Equal(t, "timed out after 10ms", report.Checks["slow"].Error)
+	assert.Equal(t, OK, report.Checks["templates"].Status)
+}
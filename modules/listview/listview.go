@@ -0,0 +1,76 @@
+package listview
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/canonical"
+)
+
+const (
+	Table = "table"
+	Grid  = "grid"
+
+	cookieName = "moviedb_view"
+	param      = "view"
+)
+
+// View is how movie listings are shown, as a table or as a grid of covers,
+// with links switching to either.
+type View struct {
+	Current string
+	Table   string
+	Grid    string
+}
+
+// New returns the view of req. Choosing one with the view query parameter remembers it in a cookie,
+// otherwise the cookie decides, the default is a table.
+func New(w http.ResponseWriter, req *http.Request) *View {
+	current := req.URL.Query().Get(param)
+	if valid(current) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookieName,
+			Value:    current,
+			Path:     "/",
+			Expires:  time.Now().AddDate(1, 0, 0),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	} else if cookie, err := req.Cookie(cookieName); err == nil && valid(cookie.Value) {
+		current = cookie.Value
+	} else {
+		current = Table
+	}
+
+	return &View{
+		Current: current,
+		Table:   link(req, Table),
+		Grid:    link(req, Grid),
+	}
+}
+
+// IsGrid returns true if the listing is shown as a grid of covers.
+func (v *View) IsGrid() bool {
+	return v != nil && v.Current == Grid
+}
+
+func valid(view string) bool {
+	return view == Table || view == Grid
+}
+
+// link returns the current page in view, starting over at its first page.
+func link(req *http.Request, view string) string {
+	query := req.URL.Query()
+	query.Set(param, view)
+	query.Del("page")
+	return canonical.Path(req.URL.Path, canonical.Encode(query))
+}
+
+// Canonical returns the view parameter of values, if it is a valid one.
+func Canonical(values url.Values) url.Values {
+	if view := values.Get(param); valid(view) {
+		return url.Values{param: {view}}
+	}
+	return url.Values{}
+}
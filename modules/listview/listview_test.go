@@ -0,0 +1,62 @@
+package listview
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListView_Default(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/movies?query=genre&value=3&page=2", nil)
+	response := httptest.NewRecorder()
+
+	view := New(response, req)
+	assert.Equal(t, Table, view.Current)
+	assert.False(t, view.IsGrid())
+	assert.Equal(t, "/movies?query=genre&value=3&view=table", view.Table)
+	assert.Equal(t, "/movies?query=genre&value=3&view=grid", view.Grid)
+	assert.Empty(t, response.Result().Cookies())
+}
+
+func Test_ListView_Remembered(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/movies?view=grid", nil)
+	response := httptest.NewRecorder()
+
+	view := New(response, req)
+	assert.True(t, view.IsGrid())
+	cookies := response.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "moviedb_view", cookies[0].Name)
+		assert.Equal(t, Grid, cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	req, _ = http.NewRequest("GET", "http://localhost/person/211", nil)
+	req.AddCookie(cookies[0])
+	assert.True(t, New(httptest.NewRecorder(), req).IsGrid())
+
+	// the parameter wins over the cookie
+	req, _ = http.NewRequest("GET", "http://localhost/person/211?view=table", nil)
+	req.AddCookie(cookies[0])
+	assert.False(t, New(httptest.NewRecorder(), req).IsGrid())
+}
+
+func Test_ListView_Invalid(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/movies?view=cards", nil)
+	req.AddCookie(&http.Cookie{Name: "moviedb_view", Value: "<script>"})
+	response := httptest.NewRecorder()
+
+	assert.Equal(t, Table, New(response, req).Current)
+	assert.Empty(t, response.Result().Cookies())
+
+	var view *View
+	assert.False(t, view.IsGrid())
+}
+
+func Test_ListView_Canonical(t *testing.T) {
+	assert.Equal(t, url.Values{"view": {"grid"}}, Canonical(url.Values{"view": {"grid", "table"}, "page": {"2"}}))
+	assert.Equal(t, url.Values{}, Canonical(url.Values{"view": {"cards"}}))
+}
@@ -0,0 +1,183 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/slug"
+)
+
+// descriptions longer than this are cut for link previews
+const maxDescription = 200
+
+// Meta is the head metadata of a page, rendered as OpenGraph and Twitter card tags
+// for link previews, and as schema.org JSON-LD for search engines.
+type Meta struct {
+	Type        string // OpenGraph type, like video.movie or profile
+	Title       string
+	Description string
+	URL         string // absolute
+	Image       string // absolute, may be empty
+	LinkedData  interface{}
+}
+
+// Card returns the Twitter card type, large images only if there is one.
+func (m *Meta) Card() string {
+	if len(m.Image) > 0 {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
+// JSONLD returns the linked data as JSON, for a <script type="application/ld+json"> element.
+// Its HTML special characters are escaped by encoding/json, it can't break out of the element.
+func (m *Meta) JSONLD() template.JS {
+	if m.LinkedData == nil {
+		return ""
+	}
+	data, err := json.Marshal(m.LinkedData)
+	if err != nil {
+		return ""
+	}
+	return template.JS(data)
+}
+
+type thing struct {
+	Context string `json:"@context,omitempty"`
+	Type    string `json:"@type"`
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+}
+
+type movie struct {
+	thing
+	AlternateName string   `json:"alternateName,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	DatePublished string   `json:"datePublished,omitempty"`
+	Duration      string   `json:"duration,omitempty"`
+	ContentRating string   `json:"contentRating,omitempty"`
+	Genre         []string `json:"genre,omitempty"`
+	InLanguage    []string `json:"inLanguage,omitempty"`
+	Director      []thing  `json:"director,omitempty"`
+	Actor         []thing  `json:"actor,omitempty"`
+}
+
+type person struct {
+	thing
+	Description string `json:"description,omitempty"`
+}
+
+// Movie returns the metadata of the page of m, base is the absolute URL of the frontend.
+func Movie(m *moviedb.Movie, base string) *Meta {
+	url := base + slug.Movie(m.Id, m.Title)
+	ld := &movie{
+		thing:         thing{"https://schema.org", "Movie", m.Title, url},
+		AlternateName: m.Alttitle.String,
+		Description:   m.Description,
+		Duration:      duration(m.Length),
+		Director:      people(m.Directors, base),
+		Actor:         people(m.Actors, base),
+	}
+	if m.Year > 0 {
+		ld.DatePublished = fmt.Sprintf("%d", m.Year)
+	}
+	if m.Rating > 0 {
+		ld.ContentRating = fmt.Sprintf("FSK %d", m.Rating)
+	}
+	for _, g := range m.Genres {
+		ld.Genre = append(ld.Genre, g.Name)
+	}
+	for _, l := range m.Languages {
+		ld.InLanguage = append(ld.InLanguage, l.Name)
+	}
+
+	meta := &Meta{
+		Type:        "video.movie",
+		Title:       m.Title,
+		Description: shorten(m.Description),
+		URL:         url,
+		LinkedData:  ld,
+	}
+	if m.Year > 0 {
+		meta.Title = fmt.Sprintf("%s (%d)", m.Title, m.Year)
+	}
+	if len(m.Picture) > 0 {
+		meta.Image = fmt.Sprintf("%s/covers/full/%s", base, m.Picture)
+		ld.Image = meta.Image
+	}
+	return meta
+}
+
+// Person returns the metadata of the page of p, with the number of movies they acted in and directed.
+func Person(p *moviedb.Person, actorIn, directorOf int, base string) *Meta {
+	url := base + slug.Person(p.Id, p.Name)
+
+	var roles []string
+	if actorIn > 0 {
+		roles = append(roles, fmt.Sprintf("actor in %d %s", actorIn, plural(actorIn, "movie")))
+	}
+	if directorOf > 0 {
+		roles = append(roles, fmt.Sprintf("director of %d %s", directorOf, plural(directorOf, "movie")))
+	}
+	var description string
+	if len(roles) > 0 {
+		description = fmt.Sprintf("%s, %s.", p.Name, strings.Join(roles, " and "))
+	}
+
+	return &Meta{
+		Type:        "profile",
+		Title:       p.Name,
+		Description: description,
+		URL:         url,
+		LinkedData: &person{
+			thing:       thing{"https://schema.org", "Person", p.Name, url},
+			Description: description,
+		},
+	}
+}
+
+func people(persons []*moviedb.Person, base string) []thing {
+	var things []thing
+	for _, p := range persons {
+		things = append(things, thing{Type: "Person", Name: p.Name, URL: base + slug.Person(p.Id, p.Name)})
+	}
+	return things
+}
+
+// duration returns minutes as ISO 8601 duration, like PT1H35M.
+func duration(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes < 60:
+		return fmt.Sprintf("PT%dM", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("PT%dH", minutes/60)
+	}
+	return fmt.Sprintf("PT%dH%dM", minutes/60, minutes%60)
+}
+
+// shorten cuts s at the last word boundary before maxDescription characters.
+func shorten(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= maxDescription {
+		return s
+	}
+	cut := string([]rune(s)[:maxDescription])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, ",.;:-") + "…"
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,88 @@
+package meta
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Meta_Movie(t *testing.T) {
+	m := Movie(&moviedb.Movie{
+		Id:          7,
+		Title:       "Zatôichi",
+		Alttitle:    sql.NullString{String: "The Blind Swordsman", Valid: true},
+		Year:        2003,
+		Description: "A blind masseur.",
+		Length:      116,
+		Rating:      16,
+		Picture:     "zatoichi.jpg",
+		Genres:      []*moviedb.Genre{{Id: 1, Name: "Action"}},
+		Directors:   []*moviedb.Person{{Id: 3, Name: "Takeshi Kitano"}},
+		Actors:      []*moviedb.Person{{Id: 3, Name: "Takeshi Kitano"}, {Id: 4, Name: "Tadanobu Asano"}},
+	}, "https://moviedb.example.com")
+
+	assert.Equal(t, "video.movie", m.Type)
+	assert.Equal(t, "Zatôichi (2003)", m.Title)
+	assert.Equal(t, "https://moviedb.example.com/movie/7-zatoichi", m.URL)
+	assert.Equal(t, "https://moviedb.example.com/covers/full/zatoichi.jpg", m.Image)
+	assert.Equal(t, "summary_large_image", m.Card())
+
+	var ld map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(m.JSONLD()), &ld))
+	assert.Equal(t, "https://schema.org", ld["@context"])
+	assert.Equal(t, "Movie", ld["@type"])
+	assert.Equal(t, "Zatôichi", ld["name"])
+	assert.Equal(t, "The Blind Swordsman", ld["alternateName"])
+	assert.Equal(t, "2003", ld["datePublished"])
+	assert.Equal(t, "PT1H56M", ld["duration"])
+	assert.Equal(t, "FSK 16", ld["contentRating"])
+	assert.Equal(t, []interface{}{"Action"}, ld["genre"])
+	assert.Equal(t, 1, len(ld["director"].([]interface{})))
+	assert.Equal(t, 2, len(ld["actor"].([]interface{})))
+	assert.Equal(t, map[string]interface{}{
+		"@type": "Person",
+		"name":  "Tadanobu Asano",
+		"url":   "https://moviedb.example.com/person/4-tadanobu-asano",
+	}, ld["actor"].([]interface{})[1])
+}
+
+func Test_Meta_MovieMinimal(t *testing.T) {
+	m := Movie(&moviedb.Movie{Id: 8, Title: "Untitled"}, "http://localhost")
+	assert.Equal(t, "Untitled", m.Title)
+	assert.Equal(t, "", m.Image)
+	assert.Equal(t, "summary", m.Card())
+	assert.Equal(t, `{"@context":"https://schema.org","@type":"Movie","name":"Untitled","url":"http://localhost/movie/8-untitled"}`, string(m.JSONLD()))
+}
+
+func Test_Meta_Person(t *testing.T) {
+	m := Person(&moviedb.Person{Id: 211, Name: "Quentin Tarantino"}, 3, 1, "http://localhost")
+	assert.Equal(t, "profile", m.Type)
+	assert.Equal(t, "http://localhost/person/211-quentin-tarantino", m.URL)
+	assert.Equal(t, "Quentin Tarantino, actor in 3 movies and director of 1 movie.", m.Description)
+	assert.Equal(t, `{"@context":"https://schema.org","@type":"Person","name":"Quentin Tarantino","url":"http://localhost/person/211-quentin-tarantino","description":"Quentin Tarantino, actor in 3 movies and director of 1 movie."}`, string(m.JSONLD()))
+}
+
+func Test_Meta_JSONLDEscaping(t *testing.T) {
+	m := Person(&moviedb.Person{Id: 1, Name: "</script><script>alert(1)</script>"}, 0, 0, "http://localhost")
+	assert.NotContains(t, string(m.JSONLD()), "</script>")
+}
+
+func Test_Meta_Duration(t *testing.T) {
+	assert.Equal(t, "", duration(0))
+	assert.Equal(t, "PT45M", duration(45))
+	assert.Equal(t, "PT2H", duration(120))
+	assert.Equal(t, "PT2H1M", duration(121))
+}
+
+func Test_Meta_Shorten(t *testing.T) {
+	assert.Equal(t, "short and sweet", shorten("  short\n and   sweet "))
+
+	long := strings.Repeat("word, ", 50)
+	short := shorten(long)
+	assert.True(t, len([]rune(short)) <= maxDescription+1)
+	assert.True(t, strings.HasSuffix(short, "word…"))
+}
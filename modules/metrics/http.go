@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	classico "github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+var (
+	requests = NewCounterVec("moviedb_frontend_http_requests_total",
+		"Number of HTTP requests handled, by route, method and status code.",
+		"route", "method", "code")
+	requestDuration = NewHistogramVec("moviedb_frontend_http_request_duration_seconds",
+		"Latency of HTTP requests, by route and method.",
+		DefaultBuckets, "route", "method")
+
+	backendRequests = NewCounterVec("moviedb_frontend_backend_requests_total",
+		"Number of requests to moviedb-backend, by path and result: success, error, or canceled by the caller.",
+		"path", "result")
+	backendDuration = NewHistogramVec("moviedb_frontend_backend_request_duration_seconds",
+		"Latency of requests to moviedb-backend, by path.",
+		DefaultBuckets, "path")
+
+	renderDuration = NewHistogramVec("moviedb_frontend_template_render_duration_seconds",
+		"Time spent rendering pages, by template. JSON and XML responses are labeled template.json and template.xml.",
+		DefaultBuckets, "template")
+
+	ids = regexp.MustCompile(`/\d+(/|$)`)
+)
+
+// Middleware is a negroni middleware counting requests and their latency per route.
+// Routes are labeled by their name, see NewRoute.
+type Middleware struct {
+	router *mux.Router
+}
+
+func NewMiddleware(router *mux.Router) *Middleware {
+	return &Middleware{router}
+}
+
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+
+	route := "unmatched"
+	var match mux.RouteMatch
+	if m.router.Match(req, &match) && len(match.Route.GetName()) > 0 {
+		route = match.Route.GetName()
+	}
+
+	next(rw, req)
+
+	status := http.StatusOK
+	if res, ok := rw.(classico.ResponseWriter); ok && res.Status() != 0 {
+		status = res.Status()
+	}
+	requests.Inc(route, req.Method, strconv.Itoa(status))
+	requestDuration.Observe(time.Since(start).Seconds(), route, req.Method)
+}
+
+// NewRoute is like web.Frontend.NewRoute, but measures the template rendering time.
+// The route is named by its path, which labels its request metrics.
+func NewRoute(f *web.Frontend, path string, handler web.Handler) *mux.Route {
+	return f.Router.Handle(path, NewHandler(f, handler)).Name(path)
+}
+
+// NewHandler is like web.Frontend.NewHandler, but measures the template rendering time.
+func NewHandler(f *web.Frontend, fn web.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var page *web.Page
+		var handled time.Time
+
+		start := time.Now()
+		f.NewHandler(func(w http.ResponseWriter, req *http.Request) *web.Page {
+			page = fn(w, req)
+			handled = time.Now()
+			return page
+		})(w, req)
+
+		template := page.Template
+		if page.Error != nil {
+			template = "error"
+		}
+		if handled.IsZero() {
+			handled = start
+		}
+		ObserveRender(template, handled)
+	}
+}
+
+// ObserveRender records the time spent rendering a page with template, which started at start.
+func ObserveRender(template string, start time.Time) {
+	renderDuration.Observe(time.Since(start).Seconds(), template)
+}
+
+type backendGetter struct {
+	getter api.Getter
+}
+
+// NewGetter returns an api.Getter counting the requests of getter and their latency per backend path.
+// Numeric path segments are replaced by {id}, i.e. /movie/{id}.
+func NewGetter(getter api.Getter) api.Getter {
+	return &backendGetter{getter}
+}
+
+func (b *backendGetter) Get(ctx context.Context, rawUrl string) (string, error) {
+	path := "unknown"
+	if u, err := url.Parse(rawUrl); err == nil {
+		path = ids.ReplaceAllString(u.Path, "/{id}$1")
+	}
+
+	start := time.Now()
+	response, err := b.getter.Get(ctx, rawUrl)
+	backendDuration.Observe(time.Since(start).Seconds(), path)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		backendRequests.Inc(path, "canceled")
+	case err != nil:
+		backendRequests.Inc(path, "error")
+	default:
+		backendRequests.Inc(path, "success")
+	}
+	return response, err
+}
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of latency histograms, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	mutex      sync.Mutex
+	collectors []collector
+)
+
+type collector interface {
+	write(w io.Writer)
+}
+
+func register(c collector) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	collectors = append(collectors, c)
+}
+
+// Handler writes all registered metrics in the Prometheus text exposition format.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteTo(w)
+	}
+}
+
+// WriteTo writes all registered metrics in the Prometheus text exposition format.
+func WriteTo(w io.Writer) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	b := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(b)
+	}
+	return b.Flush()
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mutex      sync.Mutex
+	values     map[string]*counter
+}
+
+type counter struct {
+	labels []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*counter),
+	}
+	register(c)
+	return c
+}
+
+// Inc increments the counter of the given label values by 1.
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds value to the counter of the given label values.
+func (c *CounterVec) Add(value float64, labels ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := strings.Join(labels, "\xff")
+	v, ok := c.values[key]
+	if !ok {
+		v = &counter{labels: labels}
+		c.values[key] = v
+	}
+	v.value += value
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	header(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelPairs(c.labels, v.labels), number(v.value))
+	}
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mutex      sync.Mutex
+	values     map[string]*histogram
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  make(map[string]*histogram),
+	}
+	register(h)
+	return h
+}
+
+// Observe adds a single observation to the histogram of the given label values.
+func (h *HistogramVec) Observe(value float64, labels ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := strings.Join(labels, "\xff")
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogram{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+			break
+		}
+	}
+	v.sum += value
+	v.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	header(w, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		var cumulative uint64
+		for i, bound := range append(h.buckets, math.Inf(1)) {
+			if i < len(v.counts) {
+				cumulative += v.counts[i]
+			} else {
+				cumulative = v.count
+			}
+			le := append(append([]string{}, v.labels...), number(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(bucketLabels, le), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelPairs(h.labels, v.labels), number(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelPairs(h.labels, v.labels), v.count)
+	}
+}
+
+// Func is a metric without labels whose value is read on every scrape.
+type Func struct {
+	name, help, kind string
+	fn               func() float64
+}
+
+// NewCounterFunc registers a counter whose value is returned by fn.
+func NewCounterFunc(name, help string, fn func() float64) *Func {
+	f := &Func{name, help, "counter", fn}
+	register(f)
+	return f
+}
+
+// NewGaugeFunc registers a gauge whose value is returned by fn.
+func NewGaugeFunc(name, help string, fn func() float64) *Func {
+	f := &Func{name, help, "gauge", fn}
+	register(f)
+	return f
+}
+
+func (f *Func) write(w io.Writer) {
+	header(w, f.name, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.name, number(f.fn()))
+}
+
+func header(w io.Writer, name, help, kind string) {
+	help = strings.Replace(strings.Replace(help, `\`, `\\`, -1), "\n", `\n`, -1)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelPairs(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		var value string
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelEscaper.Replace(value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func number(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch values := m.(type) {
+	case map[string]*counter:
+		for key := range values {
+			keys = append(keys, key)
+		}
+	case map[string]*histogram:
+		for key := range values {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
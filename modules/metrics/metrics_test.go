@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type getter struct {
+	err error
+}
+
+func (g *getter) Get(ctx context.Context, url string) (string, error) {
+	return url, g.err
+}
+
+func Test_Metrics_Counter(t *testing.T) {
+	c := NewCounterVec("test_counter_total", "A \"test\" counter.", "route", "code")
+	c.Inc("/movies", "200")
+	c.Inc("/movies", "200")
+	c.Add(3, `/say "hi"`, "500")
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	assert.Equal(t, `# HELP test_counter_total A "test" counter.
+# TYPE test_counter_total counter
+test_counter_total{route="/movies",code="200"} 2
+test_counter_total{route="/say \"hi\"",code="500"} 3
+`, buf.String())
+}
+
+func Test_Metrics_Histogram(t *testing.T) {
+	h := NewHistogramVec("test_duration_seconds", "A test histogram.", []float64{0.1, 1}, "path")
+	h.Observe(0.05, "/movies")
+	h.Observe(0.5, "/movies")
+	h.Observe(2, "/movies")
+
+	var buf bytes.Buffer
+	h.write(&buf)
+	assert.Equal(t, `# HELP test_duration_seconds A test histogram.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{path="/movies",le="0.1"} 1
+test_duration_seconds_bucket{path="/movies",le="1"} 2
+test_duration_seconds_bucket{path="/movies",le="+Inf"} 3
+test_duration_seconds_sum{path="/movies"} 2.55
+test_duration_seconds_count{path="/movies"} 3
+`, buf.String())
+}
+
+func Test_Metrics_Getter(t *testing.T) {
+	g := &getter{}
+	backend := NewGetter(g)
+
+	response, err := backend.Get(context.Background(), "http://backend/movie/1026")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://backend/movie/1026", response)
+
+	g.err = errors.New("backend down")
+	_, err = backend.Get(context.Background(), "http://backend/person/211?sort=title")
+	assert.Error(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = backend.Get(ctx, "http://backend/person/211")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteTo(&buf))
+	assert.Contains(t, buf.String(), `moviedb_frontend_backend_requests_total{path="/movie/{id}",result="success"} 1`)
+	assert.Contains(t, buf.String(), `moviedb_frontend_backend_requests_total{path="/person/{id}",result="error"} 1`)
+	assert.Contains(t, buf.String(), `moviedb_frontend_backend_requests_total{path="/person/{id}",result="canceled"} 1`)
+	assert.Contains(t, buf.String(), `moviedb_frontend_backend_request_duration_seconds_count{path="/movie/{id}"} 1`)
+}
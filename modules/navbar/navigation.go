@@ -1,13 +1,12 @@
 package navbar
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
-	"github.com/jamesclonk-io/stdlib/env"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
 	"github.com/jamesclonk-io/stdlib/logger"
 	"github.com/jamesclonk-io/stdlib/web"
 )
@@ -21,7 +20,7 @@ func init() {
 	log = logger.GetLogger()
 }
 
-func GetNavigation() web.Navigation {
+func buildNavigation(genreNav web.Navigation) web.Navigation {
 	moviesNav := web.Navigation{
 		web.NavigationElement{
 			Name: "by Name",
@@ -83,7 +82,7 @@ func GetNavigation() web.Navigation {
 			Name:     "Genres",
 			Link:     "#",
 			Icon:     "fa-heartbeat",
-			Dropdown: getGenreNavigation(),
+			Dropdown: genreNav,
 		},
 		web.NavigationElement{
 			Name: "People",
@@ -100,6 +99,11 @@ func GetNavigation() web.Navigation {
 				},
 			},
 		},
+		web.NavigationElement{
+			Name: "Browse",
+			Link: "/browse",
+			Icon: "fa-filter",
+		},
 		web.NavigationElement{
 			Name: "Statistics",
 			Link: "/statistics",
@@ -108,24 +112,18 @@ func GetNavigation() web.Navigation {
 	}
 }
 
-func getGenres() ([]moviedb.Genre, error) {
-	backendClient := web.NewBackendClient()
-	backendUrl := env.Get("JCIO_MOVIEDB_BACKEND", "http://moviedb-backend.jamesclonk.io")
-
-	response, err := backendClient.Get(backendUrl + "/genres")
-	if err != nil {
-		return nil, err
-	}
-
-	var genres []moviedb.Genre
-	if err := json.Unmarshal([]byte(response), &genres); err != nil {
-		return nil, err
+// Genres returns the genre dropdown of nav, it is empty if the genres could not be loaded.
+func Genres(nav web.Navigation) web.Navigation {
+	for _, element := range nav {
+		if element.Name == "Genres" {
+			return element.Dropdown
+		}
 	}
-	return genres, nil
+	return nil
 }
 
-func getGenreNavigation() web.Navigation {
-	genres, err := getGenres()
+func getGenreNavigation(source datasource.Source) web.Navigation {
+	genres, err := source.Genres(context.Background())
 	if err != nil {
 		entry := log.WithFields(logrus.Fields{
 			"error": err,
@@ -0,0 +1,83 @@
+package navbar
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+// MinBackoff is the first delay before loading the genres again, while there are none.
+var MinBackoff = time.Second
+
+// Navigation holds the current navigation and keeps its genres up to date.
+// It is safe for concurrent use, a refresh swaps the whole navigation at once.
+type Navigation struct {
+	value  atomic.Value // web.Navigation
+	genres func() web.Navigation
+}
+
+// NewNavigation returns a Navigation with the genres loaded from source, if it is reachable.
+func NewNavigation(source datasource.Source) *Navigation {
+	n := &Navigation{genres: func() web.Navigation {
+		return getGenreNavigation(source)
+	}}
+	n.Refresh()
+	return n
+}
+
+// Get returns the current navigation.
+func (n *Navigation) Get() web.Navigation {
+	nav, _ := n.value.Load().(web.Navigation)
+	return nav
+}
+
+// Refresh reloads the genres from the backend. If that fails,
+// the navigation keeps the genres it had before, if any.
+func (n *Navigation) Refresh() error {
+	genres := n.genres()
+	if len(genres) == 0 {
+		if n.value.Load() == nil {
+			n.value.Store(buildNavigation(nil))
+		}
+		return errors.New("no genres loaded")
+	}
+	n.value.Store(buildNavigation(genres))
+	return nil
+}
+
+// Run refreshes the navigation every interval, it never returns.
+// While there are no genres at all it retries sooner, backing off from MinBackoff up to interval.
+func (n *Navigation) Run(interval time.Duration) {
+	backoff := MinBackoff
+	for {
+		if len(Genres(n.Get())) > 0 {
+			backoff = MinBackoff
+			time.Sleep(interval)
+		} else {
+			log.WithFields(logrus.Fields{
+				"retry_in": backoff,
+			}).Warn("Genre navigation is empty")
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > interval {
+				backoff = interval
+			}
+		}
+		n.Refresh()
+	}
+}
+
+// Handler wraps fn to render its page with the current navigation.
+func (n *Navigation) Handler(fn web.Handler) web.Handler {
+	return func(w http.ResponseWriter, req *http.Request) *web.Page {
+		page := fn(w, req)
+		if page.Navigation == nil {
+			page.Navigation = n.Get()
+		}
+		return page
+	}
+}
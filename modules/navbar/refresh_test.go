@@ -0,0 +1,86 @@
+package navbar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/stdlib/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestNavigation(genres ...web.Navigation) *Navigation {
+	n := &Navigation{}
+	n.genres = func() web.Navigation {
+		next := genres[0]
+		if len(genres) > 1 {
+			genres = genres[1:]
+		}
+		return next
+	}
+	n.Refresh()
+	return n
+}
+
+var (
+	drama  = web.Navigation{web.NavigationElement{Name: "Drama", Link: "/movies?query=genre&value=1"}}
+	comedy = web.Navigation{web.NavigationElement{Name: "Comedy", Link: "/movies?query=genre&value=2"}}
+)
+
+func Test_Navbar_Refresh(t *testing.T) {
+	n := newTestNavigation(drama, comedy)
+	assert.Equal(t, drama, Genres(n.Get()))
+
+	assert.NoError(t, n.Refresh())
+	assert.Equal(t, comedy, Genres(n.Get()))
+}
+
+func Test_Navbar_RefreshKeepsGenres(t *testing.T) {
+	n := newTestNavigation(drama, nil, comedy)
+	assert.Equal(t, drama, Genres(n.Get()))
+
+	assert.Error(t, n.Refresh())
+	assert.Equal(t, drama, Genres(n.Get()))
+
+	assert.NoError(t, n.Refresh())
+	assert.Equal(t, comedy, Genres(n.Get()))
+}
+
+func Test_Navbar_RefreshWithoutGenres(t *testing.T) {
+	n := newTestNavigation(nil)
+	assert.NotEmpty(t, n.Get())
+	assert.Empty(t, Genres(n.Get()))
+}
+
+func Test_Navbar_Handler(t *testing.T) {
+	n := newTestNavigation(drama)
+	own := web.Navigation{web.NavigationElement{Name: "Own"}}
+
+	handler := n.Handler(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return &web.Page{Template: "index"}
+	})
+	page := handler(httptest.NewRecorder(), nil)
+	assert.Equal(t, drama, Genres(page.Navigation))
+
+	handler = n.Handler(func(w http.ResponseWriter, req *http.Request) *web.Page {
+		return &web.Page{Template: "index", Navigation: own}
+	})
+	page = handler(httptest.NewRecorder(), nil)
+	assert.Equal(t, own, page.Navigation)
+}
+
+func Test_Navbar_NewNavigation(t *testing.T) {
+	n := NewNavigation(datasource.NewBackend(api.GetterFunc(func(ctx context.Context, url string) (string, error) {
+		assert.Equal(t, "http://backend/genres", url)
+		return `[{"id":1,"name":"Drama"},{"id":2,"name":"Comedy"}]`, nil
+	}), nil, "http://backend"))
+
+	genres := Genres(n.Get())
+	if assert.Len(t, genres, 2) {
+		assert.Equal(t, "Drama", genres[0].Name)
+		assert.Equal(t, "/movies?query=genre&value=1", genres[0].Link)
+	}
+}
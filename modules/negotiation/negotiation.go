@@ -0,0 +1,129 @@
+package negotiation
+
+import (
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/metrics"
+	"github.com/jamesclonk-io/stdlib/web"
+)
+
+const (
+	HTML = "html"
+	JSON = "json"
+	XML  = "xml"
+)
+
+var mediaTypes = map[string]string{
+	"text/html":             HTML,
+	"application/xhtml+xml": HTML,
+	"application/json":      JSON,
+	"text/json":             JSON,
+	"application/xml":       XML,
+	"text/xml":              XML,
+}
+
+// Stale is implemented by the web.Page.Data of pages rendered from stale backend responses.
+type Stale interface {
+	IsStale() (bool, time.Time)
+}
+
+type xmlError struct {
+	XMLName xml.Name `xml:"error"`
+	Message string   `xml:",chardata"`
+}
+
+// NewRoute is like web.Frontend.NewRoute, but renders the page content
+// as JSON or XML instead of HTML if the request asks for it.
+// The route is named by its path, which labels its request metrics.
+func NewRoute(f *web.Frontend, path string, handler web.Handler) *mux.Route {
+	return f.Router.Handle(path, NewHandler(f, handler)).Name(path)
+}
+
+func NewHandler(f *web.Frontend, fn web.Handler) http.HandlerFunc {
+	html := metrics.NewHandler(f, fn)
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		format := Format(req)
+		if format == HTML {
+			html(w, req)
+			return
+		}
+
+		page := fn(w, req)
+		if page.StatusCode == 0 {
+			page.StatusCode = http.StatusOK
+		}
+		// API clients don't get to see the notice of the HTML page
+		if data, ok := page.Data.(Stale); ok {
+			if stale, _ := data.IsStale(); stale {
+				w.Header().Set("Warning", `110 - "Response is Stale"`)
+			}
+		}
+
+		template := page.Template
+		if page.Error != nil {
+			template = "error"
+		}
+		defer metrics.ObserveRender(template+"."+format, time.Now())
+
+		switch {
+		case format == JSON && page.Error != nil:
+			f.Render.JSON(w, page.StatusCode, page.Error.Error())
+		case format == JSON:
+			f.Render.JSON(w, page.StatusCode, page.Content)
+		case page.Error != nil:
+			f.Render.XML(w, page.StatusCode, &xmlError{Message: page.Error.Error()})
+		default:
+			f.Render.XML(w, page.StatusCode, XMLContent(page.Content))
+		}
+	}
+}
+
+// Format returns the response format requested by either the format
+// query parameter or the Accept header, defaulting to HTML.
+func Format(req *http.Request) string {
+	switch format := strings.ToLower(req.URL.Query().Get("format")); format {
+	case HTML, JSON, XML:
+		return format
+	}
+
+	format, quality := HTML, 0.0
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		f, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		// on equal quality, HTML wins over the others
+		if q > quality || (q == quality && f == HTML) {
+			format, quality = f, q
+		}
+	}
+	return format
+}
+
+// Canonical returns the format parameter of values, if it is a valid one.
+func Canonical(values url.Values) url.Values {
+	switch format := strings.ToLower(values.Get("format")); format {
+	case HTML, JSON, XML:
+		return url.Values{"format": {format}}
+	}
+	return url.Values{}
+}
@@ -0,0 +1,62 @@
+package negotiation
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Negotiation_Format(t *testing.T) {
+	tests := []struct {
+		url, accept, format string
+	}{
+		{"/movies", "", HTML},
+		{"/movies", "*/*", HTML},
+		{"/movies", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HTML},
+		{"/movies", "application/json", JSON},
+		{"/movies", "application/xml, application/json;q=0.5", XML},
+		{"/movies", "text/html;q=0.1, text/xml", XML},
+		{"/movies?format=json", "text/html", JSON},
+		{"/movies?format=XML", "", XML},
+		{"/movies?format=yaml", "application/json", JSON},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", "http://localhost"+test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", test.accept)
+		assert.Equal(t, test.format, Format(req), test.url+" / "+test.accept)
+	}
+}
+
+func Test_Negotiation_XMLContent(t *testing.T) {
+	data, err := xml.Marshal(XMLContent([]moviedb.MovieListing{
+		{Id: 1026, Title: "Army of Darkness", Year: 1992, Score: 4, Rating: 16},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, `<movies><movie id="1026"><title>Army of Darkness</title><year>1992</year><score>4</score><rating>16</rating></movie></movies>`, string(data))
+
+	data, err = xml.Marshal(XMLContent(moviedb.Movie{
+		Id:     1026,
+		Title:  "Army of Darkness",
+		Genres: []*moviedb.Genre{{Id: 9, Name: "Horror"}},
+	}))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `<movie id="1026"><title>Army of Darkness</title>`)
+	assert.Contains(t, string(data), `<genres><genre id="9"><name>Horror</name></genre></genres>`)
+
+	data, err = xml.Marshal(XMLContent([]moviedb.Person{{Id: 211, Name: "Quentin Tarantino"}}))
+	assert.NoError(t, err)
+	assert.Equal(t, `<people><person id="211"><name>Quentin Tarantino</name></person></people>`, string(data))
+}
+
+func Test_Negotiation_Canonical(t *testing.T) {
+	assert.Equal(t, url.Values{"format": {"json"}}, Canonical(url.Values{"format": {"JSON"}, "view": {"grid"}}))
+	assert.Equal(t, url.Values{}, Canonical(url.Values{"format": {"yaml"}}))
+}
@@ -0,0 +1,119 @@
+package negotiation
+
+import (
+	"encoding/xml"
+
+	"github.com/jamesclonk-io/moviedb-backend/modules/moviedb"
+)
+
+// XMLer is implemented by page contents which need a different representation as XML.
+type XMLer interface {
+	XML() interface{}
+}
+
+// The xml tags of moviedb.Movie and moviedb.MovieListing map both Year and Score to
+// "year", which encoding/xml refuses to marshal. These mirror types fix that,
+// all other moviedb types are used as they are.
+type MovieListing struct {
+	Id     int    `xml:"id,attr"`
+	Title  string `xml:"title"`
+	Year   int    `xml:"year"`
+	Score  int    `xml:"score"`
+	Rating int    `xml:"rating"`
+}
+
+type Movie struct {
+	XMLName     xml.Name            `xml:"movie"`
+	Id          int                 `xml:"id,attr"`
+	Title       string              `xml:"title"`
+	Alttitle    string              `xml:"alttitle,omitempty"`
+	Year        int                 `xml:"year"`
+	Description string              `xml:"description"`
+	Format      string              `xml:"format"`
+	Length      int                 `xml:"length"`
+	Region      string              `xml:"region"`
+	Rating      int                 `xml:"rating"`
+	Disks       int                 `xml:"disks"`
+	Score       int                 `xml:"score"`
+	Picture     string              `xml:"picture"`
+	Type        string              `xml:"type"`
+	Languages   []*moviedb.Language `xml:"languages>language"`
+	Genres      []*moviedb.Genre    `xml:"genres>genre"`
+	Actors      []*moviedb.Person   `xml:"actors>actor"`
+	Directors   []*moviedb.Person   `xml:"directors>director"`
+}
+
+type movieListings struct {
+	XMLName xml.Name       `xml:"movies"`
+	Movies  []MovieListing `xml:"movie"`
+}
+
+type people struct {
+	XMLName xml.Name         `xml:"people"`
+	People  []moviedb.Person `xml:"person"`
+}
+
+type person struct {
+	XMLName xml.Name `xml:"person"`
+	moviedb.Person
+}
+
+type statistics struct {
+	XMLName xml.Name `xml:"statistics"`
+	moviedb.Statistics
+}
+
+// XMLContent returns the XML representation of a page content.
+func XMLContent(content interface{}) interface{} {
+	switch c := content.(type) {
+	case XMLer:
+		return c.XML()
+	case []moviedb.MovieListing:
+		return &movieListings{Movies: MovieListings(c)}
+	case moviedb.Movie:
+		return NewMovie(&c)
+	case []moviedb.Person:
+		return &people{People: c}
+	case moviedb.Person:
+		return &person{Person: c}
+	case moviedb.Statistics:
+		return &statistics{Statistics: c}
+	}
+	return content
+}
+
+func MovieListings(listings []moviedb.MovieListing) []MovieListing {
+	result := make([]MovieListing, 0, len(listings))
+	for _, l := range listings {
+		result = append(result, MovieListing{
+			Id:     l.Id,
+			Title:  l.Title,
+			Year:   l.Year,
+			Score:  l.Score,
+			Rating: l.Rating,
+		})
+	}
+	return result
+}
+
+func NewMovie(m *moviedb.Movie) *Movie {
+	return &Movie{
+		Id:          m.Id,
+		Title:       m.Title,
+		Alttitle:    m.Alttitle.String,
+		Year:        m.Year,
+		Description: m.Description,
+		Format:      m.Format,
+		Length:      m.Length,
+		Region:      m.Region,
+		Rating:      m.Rating,
+		Disks:       m.Disks,
+		Score:       m.Score,
+		Picture:     m.Picture,
+		Type:        m.Type,
+		Languages:   m.Languages,
+		Genres:      m.Genres,
+		Actors:      m.Actors,
+		Directors:   m.Directors,
+	}
+}
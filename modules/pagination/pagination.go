@@ -0,0 +1,167 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/canonical"
+)
+
+const MaxPerPage = 1000
+
+// Pagination describes the current page of a listing and links to its neighbours.
+type Pagination struct {
+	Page    int
+	PerPage int
+	Total   int // total number of items, -1 if unknown
+	hasNext bool
+	path    string
+	query   url.Values
+}
+
+// Link is a single page number control.
+type Link struct {
+	Number int
+	URL    string
+	Active bool
+	Gap    bool
+}
+
+// New reads the page and per_page query parameters of req, using perPage as default.
+func New(req *http.Request, perPage int) *Pagination {
+	query := req.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	if value, err := strconv.Atoi(query.Get("per_page")); err == nil && value > 0 {
+		perPage = value
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+
+	return &Pagination{
+		Page:    page,
+		PerPage: perPage,
+		Total:   -1,
+		path:    req.URL.Path,
+		query:   query,
+	}
+}
+
+// Apply updates the pagination with the number of items the backend returned and
+// returns the bounds of the current page within them. If the backend did not
+// paginate the items itself, the page is cut out of the full list.
+func (p *Pagination) Apply(count int, paginated bool) (start, end int) {
+	if paginated {
+		p.hasNext = count >= p.PerPage
+		return 0, count
+	}
+
+	p.Total = count
+	p.hasNext = p.Page < p.PageCount()
+	start = (p.Page - 1) * p.PerPage
+	if start > count {
+		start = count
+	}
+	end = start + p.PerPage
+	if end > count {
+		end = count
+	}
+	return start, end
+}
+
+// PageCount returns the number of pages, or 0 if unknown.
+func (p *Pagination) PageCount() int {
+	if p.Total < 0 {
+		return 0
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+func (p *Pagination) HasPrev() bool {
+	return p.Page > 1
+}
+
+func (p *Pagination) HasNext() bool {
+	return p.hasNext
+}
+
+// Multiple reports whether there is more than a single page to navigate.
+func (p *Pagination) Multiple() bool {
+	return p.HasPrev() || p.HasNext()
+}
+
+func (p *Pagination) Prev() string {
+	return p.URL(p.Page - 1)
+}
+
+func (p *Pagination) Next() string {
+	return p.URL(p.Page + 1)
+}
+
+// URL returns the link to page, keeping all other query parameters.
+func (p *Pagination) URL(page int) string {
+	query := url.Values{}
+	for key, values := range p.query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(p.PerPage))
+	return canonical.Path(p.path, canonical.Encode(query))
+}
+
+// Pages returns the page number controls, with gaps for long listings.
+func (p *Pagination) Pages() []Link {
+	count := p.PageCount()
+	if count == 0 {
+		// total is unknown, only show the current page
+		return []Link{{Number: p.Page, URL: p.URL(p.Page), Active: true}}
+	}
+
+	var links []Link
+	for i := 1; i <= count; i++ {
+		if i == 1 || i == count || (i >= p.Page-2 && i <= p.Page+2) {
+			links = append(links, Link{Number: i, URL: p.URL(i), Active: i == p.Page})
+		} else if len(links) > 0 && !links[len(links)-1].Gap {
+			links = append(links, Link{Gap: true})
+		}
+	}
+	return links
+}
+
+// LinkHeader returns the value of a RFC 5988 Link header for the neighbouring pages.
+func (p *Pagination) LinkHeader() string {
+	var links []string
+	if p.HasPrev() {
+		links = append(links, fmt.Sprintf(`<%s>; rel="first"`, p.URL(1)))
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.Prev()))
+	}
+	if p.HasNext() {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next()))
+		if count := p.PageCount(); count > 0 {
+			links = append(links, fmt.Sprintf(`<%s>; rel="last"`, p.URL(count)))
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+// Canonical returns the valid page and per_page parameters of values, the way New reads them.
+func Canonical(values url.Values) url.Values {
+	params := url.Values{}
+	if page, err := strconv.Atoi(values.Get("page")); err == nil && page > 0 {
+		params.Set("page", strconv.Itoa(page))
+	}
+	if perPage, err := strconv.Atoi(values.Get("per_page")); err == nil && perPage > 0 {
+		if perPage > MaxPerPage {
+			perPage = MaxPerPage
+		}
+		params.Set("per_page", strconv.Itoa(perPage))
+	}
+	return params
+}
@@ -0,0 +1,84 @@
+package pagination
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPagination(t *testing.T, url string, perPage int) *Pagination {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(req, perPage)
+}
+
+func Test_Pagination_Defaults(t *testing.T) {
+	p := newPagination(t, "http://localhost/movies?page=abc&per_page=-1", 50)
+	assert.Equal(t, 1, p.Page)
+	assert.Equal(t, 50, p.PerPage)
+
+	p = newPagination(t, "http://localhost/movies?page=3&per_page=999999", 50)
+	assert.Equal(t, 3, p.Page)
+	assert.Equal(t, MaxPerPage, p.PerPage)
+}
+
+func Test_Pagination_Slice(t *testing.T) {
+	p := newPagination(t, "http://localhost/movies?query=genre&value=1&page=2&per_page=10", 50)
+
+	start, end := p.Apply(25, false)
+	assert.Equal(t, 10, start)
+	assert.Equal(t, 20, end)
+	assert.Equal(t, 3, p.PageCount())
+	assert.True(t, p.HasPrev())
+	assert.True(t, p.HasNext())
+	assert.Equal(t, "/movies?query=genre&value=1&page=1&per_page=10", p.Prev())
+	assert.Equal(t, "/movies?query=genre&value=1&page=3&per_page=10", p.Next())
+
+	p = newPagination(t, "http://localhost/movies?page=3&per_page=10", 50)
+	start, end = p.Apply(25, false)
+	assert.Equal(t, 20, start)
+	assert.Equal(t, 25, end)
+	assert.False(t, p.HasNext())
+
+	p = newPagination(t, "http://localhost/movies?page=9&per_page=10", 50)
+	start, end = p.Apply(25, false)
+	assert.Equal(t, 25, start)
+	assert.Equal(t, 25, end)
+}
+
+func Test_Pagination_Backend(t *testing.T) {
+	p := newPagination(t, "http://localhost/actors?page=2&per_page=10", 50)
+
+	start, end := p.Apply(10, true)
+	assert.Equal(t, 0, start)
+	assert.Equal(t, 10, end)
+	assert.Equal(t, -1, p.Total)
+	assert.True(t, p.HasNext())
+	assert.Equal(t, `</actors?page=1&per_page=10>; rel="first", </actors?page=1&per_page=10>; rel="prev", </actors?page=3&per_page=10>; rel="next"`, p.LinkHeader())
+
+	p.Apply(3, true)
+	assert.False(t, p.HasNext())
+}
+
+func Test_Pagination_Pages(t *testing.T) {
+	p := newPagination(t, "http://localhost/movies?page=6", 10)
+	p.Apply(200, false)
+
+	var numbers []int
+	for _, link := range p.Pages() {
+		numbers = append(numbers, link.Number)
+	}
+	assert.Equal(t, []int{1, 0, 4, 5, 6, 7, 8, 0, 20}, numbers)
+	assert.True(t, p.Pages()[4].Active)
+	assert.Equal(t, `</movies?page=1&per_page=10>; rel="first", </movies?page=5&per_page=10>; rel="prev", </movies?page=7&per_page=10>; rel="next", </movies?page=20&per_page=10>; rel="last"`, p.LinkHeader())
+}
+
+func Test_Pagination_Canonical(t *testing.T) {
+	assert.Equal(t, url.Values{"page": {"2"}, "per_page": {"10"}}, Canonical(url.Values{"page": {"02"}, "per_page": {"10"}, "sort": {"title"}}))
+	assert.Equal(t, url.Values{"per_page": {"1000"}}, Canonical(url.Values{"page": {"0"}, "per_page": {"99999"}}))
+	assert.Equal(t, url.Values{}, Canonical(url.Values{"page": {"abc"}, "per_page": {"-1"}}))
+}
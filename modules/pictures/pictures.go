@@ -0,0 +1,134 @@
+package pictures
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+var log *logrus.Logger
+
+func init() {
+	log = logger.GetLogger()
+}
+
+// concurrency is how many movies are fetched from the backend at once.
+const concurrency = 8
+
+type entry struct {
+	picture string
+	created time.Time
+}
+
+// Pictures looks up the cover pictures of movie listings, which only the movie details have.
+// They are fetched in batches and kept in memory, listings are shown over and over again.
+type Pictures struct {
+	source datasource.Source
+	ttl    time.Duration
+
+	mutex   sync.Mutex
+	entries map[int]entry
+	now     func() time.Time
+}
+
+// New returns the Pictures of the movies of source, cached for ttl.
+func New(source datasource.Source, ttl time.Duration) *Pictures {
+	return &Pictures{
+		source:  source,
+		ttl:     ttl,
+		entries: make(map[int]entry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the pictures of the movies ids, by id. Only those not cached are fetched,
+// concurrently, until ctx is done. Movies that can't be fetched are missing, they get a placeholder cover.
+func (p *Pictures) Get(ctx context.Context, ids []int) map[int]string {
+	pictures := make(map[int]string, len(ids))
+	var missing []int
+	seen := make(map[int]bool, len(ids))
+
+	p.mutex.Lock()
+	now := p.now()
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if e, ok := p.entries[id]; ok && now.Sub(e.created) < p.ttl {
+			pictures[id] = e.picture
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	p.mutex.Unlock()
+	if len(missing) == 0 {
+		return pictures
+	}
+
+	type result struct {
+		id      int
+		picture string
+		err     error
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+	workers := concurrency
+	if len(missing) < workers {
+		workers = len(missing)
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for id := range jobs {
+				picture, err := p.fetch(ctx, id)
+				results <- result{id, picture, err}
+			}
+		}()
+	}
+	go func() {
+		for _, id := range missing {
+			jobs <- id
+		}
+		close(jobs)
+	}()
+
+	fetched := make(map[int]entry, len(missing))
+	for range missing {
+		r := <-results
+		if r.err != nil {
+			log.WithFields(logrus.Fields{
+				"error": r.err,
+				"id":    r.id,
+			}).Warn("Could not get movie picture")
+			continue
+		}
+		pictures[r.id] = r.picture
+		fetched[r.id] = entry{r.picture, now}
+	}
+
+	p.mutex.Lock()
+	for id, e := range fetched {
+		p.entries[id] = e
+	}
+	p.mutex.Unlock()
+	return pictures
+}
+
+// Flush forgets all pictures, i.e. after a movie has been changed.
+func (p *Pictures) Flush() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.entries = make(map[int]entry)
+}
+
+func (p *Pictures) fetch(ctx context.Context, id int) (string, error) {
+	movie, err := p.source.Movie(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return movie.Picture, nil
+}
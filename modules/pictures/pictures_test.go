@@ -0,0 +1,81 @@
+package pictures
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGetter struct {
+	mutex sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeGetter) Get(ctx context.Context, url string) (string, error) {
+	f.mutex.Lock()
+	f.calls[url]++
+	f.mutex.Unlock()
+
+	id := strings.TrimPrefix(url, "http://backend/movie/")
+	if id == "404" {
+		return "", fmt.Errorf("not found: %s", url)
+	}
+	return fmt.Sprintf(`{"id":%s,"picture":"movie_%s.jpg"}`, id, id), nil
+}
+
+func (f *fakeGetter) Calls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var n int
+	for _, c := range f.calls {
+		n += c
+	}
+	return n
+}
+
+func Test_Pictures_Get(t *testing.T) {
+	getter := &fakeGetter{calls: make(map[string]int)}
+	p := New(datasource.NewBackend(getter, nil, "http://backend"), time.Hour)
+
+	var ids []int
+	for id := 1; id <= 20; id++ {
+		ids = append(ids, id)
+	}
+	pictures := p.Get(context.Background(), append(ids, 404, 1))
+	assert.Len(t, pictures, 20)
+	assert.Equal(t, "movie_1.jpg", pictures[1])
+	assert.Equal(t, "movie_20.jpg", pictures[20])
+	_, ok := pictures[404]
+	assert.False(t, ok)
+	assert.Equal(t, 21, getter.Calls())
+
+	// only the failed one is fetched again
+	pictures = p.Get(context.Background(), []int{1, 2, 404})
+	assert.Len(t, pictures, 2)
+	assert.Equal(t, 22, getter.Calls())
+}
+
+func Test_Pictures_Expiry(t *testing.T) {
+	getter := &fakeGetter{calls: make(map[string]int)}
+	p := New(datasource.NewBackend(getter, nil, "http://backend"), time.Hour)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	p.Get(context.Background(), []int{1})
+	p.Get(context.Background(), []int{1})
+	assert.Equal(t, 1, getter.Calls())
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, "movie_1.jpg", p.Get(context.Background(), []int{1})[1])
+	assert.Equal(t, 2, getter.Calls())
+
+	p.Flush()
+	p.Get(context.Background(), []int{1})
+	assert.Equal(t, 3, getter.Calls())
+}
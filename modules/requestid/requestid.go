@@ -0,0 +1,50 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Header carries the request ID, both in requests and responses.
+const Header = "X-Request-ID"
+
+type key struct{}
+
+// valid request IDs passed on by a proxy, anything else is replaced
+var valid = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware is a negroni middleware giving every request an ID, unless a proxy already did.
+// The ID is returned in the X-Request-ID response header.
+type Middleware struct{}
+
+func NewMiddleware() *Middleware {
+	return &Middleware{}
+}
+
+func (m *Middleware) ServeHTTP(rw http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	id := req.Header.Get(Header)
+	if !valid.MatchString(id) {
+		id = New()
+	}
+	rw.Header().Set(Header, id)
+	next(rw, req.WithContext(context.WithValue(req.Context(), key{}, id)))
+}
+
+// New returns a random request ID.
+func New() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Get returns the ID of req, or an empty string if it has none.
+func Get(req *http.Request) string {
+	if req == nil {
+		return ""
+	}
+	id, _ := req.Context().Value(key{}).(string)
+	return id
+}
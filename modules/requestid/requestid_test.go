@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(header string) (*httptest.ResponseRecorder, string) {
+	var id string
+	req, _ := http.NewRequest("GET", "/", nil)
+	if len(header) > 0 {
+		req.Header.Set(Header, header)
+	}
+	rec := httptest.NewRecorder()
+	NewMiddleware().ServeHTTP(rec, req, func(w http.ResponseWriter, req *http.Request) {
+		id = Get(req)
+	})
+	return rec, id
+}
+
+func Test_RequestID_New(t *testing.T) {
+	rec, id := serve("")
+	assert.Equal(t, 16, len(id))
+	assert.Equal(t, id, rec.Header().Get(Header))
+
+	_, other := serve("")
+	assert.NotEqual(t, id, other)
+}
+
+func Test_RequestID_PassedOn(t *testing.T) {
+	rec, id := serve("abc-123")
+	assert.Equal(t, "abc-123", id)
+	assert.Equal(t, "abc-123", rec.Header().Get(Header))
+}
+
+func Test_RequestID_Invalid(t *testing.T) {
+	_, id := serve(`<script>alert("hi")</script>`)
+	assert.Equal(t, 16, len(id))
+}
+
+func Test_RequestID_Missing(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", Get(req))
+	assert.Equal(t, "", Get(nil))
+}
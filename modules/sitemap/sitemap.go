@@ -0,0 +1,171 @@
+package sitemap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/slug"
+)
+
+// MaxURLs is the most URLs a single sitemap may list, by the sitemaps.org protocol.
+const MaxURLs = 50000
+
+// Pages are the listing pages, listed before all movies and people.
+var Pages = []string{"/", "/movies", "/browse", "/actors", "/directors", "/statistics"}
+
+// ErrNoChunk is returned for sitemap chunks beyond the last one.
+var ErrNoChunk = errors.New("no such sitemap")
+
+// Sitemap lists all pages of the frontend. The list is built from the backend and
+// kept until the movie database has been updated, according to its statistics.
+type Sitemap struct {
+	source datasource.Source
+	size   int
+
+	mutex   sync.Mutex
+	paths   []string
+	updated time.Time
+}
+
+// New returns a Sitemap split into chunks of at most size URLs.
+func New(source datasource.Source, size int) *Sitemap {
+	if size <= 0 || size > MaxURLs {
+		size = MaxURLs
+	}
+	return &Sitemap{
+		source: source,
+		size:   size,
+	}
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []entry  `xml:"url"`
+}
+
+type index struct {
+	XMLName  xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []entry  `xml:"sitemap"`
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Index returns /sitemap.xml. It lists all pages itself if they fit into a single chunk,
+// otherwise it is a sitemap index of /sitemap-1.xml, /sitemap-2.xml and so on.
+func (s *Sitemap) Index(base string) ([]byte, error) {
+	paths, updated, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) <= s.size {
+		return s.urlSet(base, paths, updated)
+	}
+
+	lastmod := updated.UTC().Format(time.RFC3339)
+	idx := index{}
+	for n := 1; (n-1)*s.size < len(paths); n++ {
+		idx.Sitemaps = append(idx.Sitemaps, entry{fmt.Sprintf("%s/sitemap-%d.xml", base, n), lastmod})
+	}
+	return marshal(idx)
+}
+
+// Chunk returns /sitemap-n.xml, counting from 1.
+func (s *Sitemap) Chunk(base string, n int) ([]byte, error) {
+	paths, updated, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	start := (n - 1) * s.size
+	if n < 1 || start >= len(paths) || len(paths) <= s.size {
+		return nil, ErrNoChunk
+	}
+	end := start + s.size
+	if end > len(paths) {
+		end = len(paths)
+	}
+	return s.urlSet(base, paths[start:end], updated)
+}
+
+// Robots returns /robots.txt, pointing to the sitemap.
+func Robots(base string) []byte {
+	var b bytes.Buffer
+	b.WriteString("User-agent: *\n")
+	for _, path := range []string{"/admin", "/error/", "/healthz", "/metrics", "/movies.", "/ready", "/suggest"} {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", base)
+	return b.Bytes()
+}
+
+func (s *Sitemap) urlSet(base string, paths []string, updated time.Time) ([]byte, error) {
+	lastmod := updated.UTC().Format(time.RFC3339)
+	set := urlSet{URLs: make([]entry, 0, len(paths))}
+	for _, path := range paths {
+		set.URLs = append(set.URLs, entry{base + path, lastmod})
+	}
+	return marshal(set)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// load returns the paths of all pages, rebuilding them only if the backend has been updated since.
+func (s *Sitemap) load() ([]string, time.Time, error) {
+	ctx := context.Background()
+	stats, err := s.source.Statistics(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.paths != nil && s.updated.Equal(stats.LastUpdate) {
+		return s.paths, s.updated, nil
+	}
+
+	movies, err := s.source.Movies(ctx, api.Movies().OrderBy(api.Id, api.Asc))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	actors, err := s.source.Actors(ctx, api.Page{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	directors, err := s.source.Directors(ctx, api.Page{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	paths := make([]string, 0, len(Pages)+len(movies)+len(actors)+len(directors))
+	paths = append(paths, Pages...)
+	for _, m := range movies {
+		paths = append(paths, slug.Movie(m.Id, m.Title))
+	}
+	// people can be both actors and directors, but have only one page
+	people := make(map[int]bool)
+	for _, p := range append(actors, directors...) {
+		if !people[p.Id] {
+			people[p.Id] = true
+			paths = append(paths, slug.Person(p.Id, p.Name))
+		}
+	}
+
+	s.paths = paths
+	s.updated = stats.LastUpdate
+	return s.paths, s.updated, nil
+}
@@ -0,0 +1,126 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/stretchr/testify/assert"
+)
+
+type backend struct {
+	mutex      sync.Mutex
+	lastUpdate string
+	calls      map[string]int
+}
+
+func newBackend() *backend {
+	return &backend{lastUpdate: "2015-06-14T00:00:00Z", calls: make(map[string]int)}
+}
+
+func (b *backend) Get(ctx context.Context, url string) (string, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	path := strings.TrimPrefix(url, "http://backend")
+	b.calls[path]++
+	switch path {
+	case "/statistics":
+		return fmt.Sprintf(`{"last_update":"%s"}`, b.lastUpdate), nil
+	case "/movies?sort=id&by=asc":
+		return `[{"id":1,"title":"A"},{"id":2,"title":"B"}]`, nil
+	case "/actors":
+		return `[{"id":10,"name":"X"},{"id":11,"name":"Y"}]`, nil
+	case "/directors":
+		return `[{"id":11,"name":"Y"},{"id":12,"name":"Z"}]`, nil
+	}
+	return "", fmt.Errorf("unknown path %s", path)
+}
+
+func newSource(b *backend) datasource.Source {
+	return datasource.NewBackend(b, nil, "http://backend")
+}
+
+type result struct {
+	XMLName  xml.Name
+	URLs     []entry `xml:"url"`
+	Sitemaps []entry `xml:"sitemap"`
+}
+
+func parse(t *testing.T, data []byte) *result {
+	var r result
+	assert.NoError(t, xml.Unmarshal(data, &r))
+	return &r
+}
+
+func Test_Sitemap_Single(t *testing.T) {
+	s := New(newSource(newBackend()), 0)
+	data, err := s.Index("https://example.com")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), xml.Header))
+
+	r := parse(t, data)
+	assert.Equal(t, "urlset", r.XMLName.Local)
+	assert.Equal(t, "http://www.sitemaps.org/schemas/sitemap/0.9", r.XMLName.Space)
+	// 6 listing pages, 2 movies and 3 distinct people
+	assert.Equal(t, 11, len(r.URLs))
+	assert.Equal(t, entry{"https://example.com/", "2015-06-14T00:00:00Z"}, r.URLs[0])
+	assert.Equal(t, "https://example.com/movie/1-a", r.URLs[6].Loc)
+	assert.Equal(t, "https://example.com/person/12-z", r.URLs[10].Loc)
+
+	_, err = s.Chunk("https://example.com", 1)
+	assert.Equal(t, ErrNoChunk, err)
+}
+
+func Test_Sitemap_Chunked(t *testing.T) {
+	s := New(newSource(newBackend()), 4)
+	data, err := s.Index("https://example.com")
+	assert.NoError(t, err)
+
+	r := parse(t, data)
+	assert.Equal(t, "sitemapindex", r.XMLName.Local)
+	assert.Equal(t, []entry{
+		{"https://example.com/sitemap-1.xml", "2015-06-14T00:00:00Z"},
+		{"https://example.com/sitemap-2.xml", "2015-06-14T00:00:00Z"},
+		{"https://example.com/sitemap-3.xml", "2015-06-14T00:00:00Z"},
+	}, r.Sitemaps)
+
+	data, err = s.Chunk("https://example.com", 3)
+	assert.NoError(t, err)
+	r = parse(t, data)
+	assert.Equal(t, "urlset", r.XMLName.Local)
+	assert.Equal(t, 3, len(r.URLs))
+	assert.Equal(t, "https://example.com/person/10-x", r.URLs[0].Loc)
+
+	for _, n := range []int{0, 4, -1} {
+		_, err = s.Chunk("https://example.com", n)
+		assert.Equal(t, ErrNoChunk, err)
+	}
+}
+
+func Test_Sitemap_Cached(t *testing.T) {
+	b := newBackend()
+	s := New(newSource(b), 0)
+
+	s.Index("https://example.com")
+	s.Index("https://example.com")
+	assert.Equal(t, 2, b.calls["/statistics"])
+	assert.Equal(t, 1, b.calls["/movies?sort=id&by=asc"])
+
+	b.lastUpdate = "2015-07-01T00:00:00Z"
+	data, err := s.Index("https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, b.calls["/movies?sort=id&by=asc"])
+	assert.Equal(t, "2015-07-01T00:00:00Z", parse(t, data).URLs[0].LastMod)
+}
+
+func Test_Sitemap_Robots(t *testing.T) {
+	robots := string(Robots("https://example.com"))
+	assert.True(t, strings.HasPrefix(robots, "User-agent: *\n"))
+	assert.Contains(t, robots, "Disallow: /metrics\n")
+	assert.True(t, strings.HasSuffix(robots, "\nSitemap: https://example.com/sitemap.xml\n"))
+}
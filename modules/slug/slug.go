@@ -0,0 +1,102 @@
+package slug
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the maximum length of a slug, longer ones are cut off at a word boundary.
+const MaxLength = 60
+
+// transliterations of letters to ASCII, as far as the movie titles and names need them.
+// Combining marks are dropped, other runes separate words.
+var transliterations = make(map[rune]string)
+
+func init() {
+	for _, t := range []struct {
+		ascii, runes string
+	}{
+		// Latin
+		{"a", "àáâãäåāăąǎǟǡǻȁȃȧ"}, {"ae", "æǣǽ"}, {"c", "çćĉċč"}, {"d", "ďđð"}, {"e", "èéêëēĕėęěȅȇȩ"},
+		{"g", "ĝğġģǧǵ"}, {"h", "ĥħ"}, {"i", "ìíîïĩīĭįıǐȉȋ"}, {"ij", "ĳ"}, {"j", "ĵǰ"}, {"k", "ķǩ"},
+		{"l", "ĺļľŀł"}, {"n", "ñńņňŉŋǹ"}, {"o", "òóôõöøōŏőǒǫǭǿȍȏȫȭȯȱ"}, {"oe", "œ"}, {"r", "ŕŗřȑȓ"},
+		{"s", "śŝşšș"}, {"ss", "ß"}, {"t", "ţťŧț"}, {"th", "þ"}, {"u", "ùúûüũūŭůűųǔǖǘǚǜȕȗ"}, {"w", "ŵ"},
+		{"y", "ýÿŷȳ"}, {"z", "źżžƶ"},
+		// Greek
+		{"a", "αά"}, {"v", "β"}, {"g", "γ"}, {"d", "δ"}, {"e", "εέ"}, {"z", "ζ"}, {"i", "ηήιίϊΐ"},
+		{"th", "θ"}, {"k", "κ"}, {"l", "λ"}, {"m", "μ"}, {"n", "ν"}, {"x", "ξ"}, {"o", "οόωώ"}, {"p", "π"},
+		{"r", "ρ"}, {"s", "σς"}, {"t", "τ"}, {"y", "υύϋΰ"}, {"f", "φ"}, {"ch", "χ"}, {"ps", "ψ"},
+		// Cyrillic
+		{"a", "а"}, {"b", "б"}, {"v", "в"}, {"g", "гґ"}, {"d", "д"}, {"e", "еёэ"}, {"zh", "ж"}, {"z", "з"},
+		{"i", "иі"}, {"y", "йы"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"},
+		{"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"}, {"f", "ф"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"},
+		{"sh", "ш"}, {"shch", "щ"}, {"", "ъь"}, {"yu", "ю"}, {"ya", "я"}, {"ye", "є"}, {"yi", "ї"},
+	} {
+		for _, r := range t.runes {
+			transliterations[r] = t.ascii
+		}
+	}
+}
+
+// Make returns s as slug: transliterated to lower case ASCII letters and digits, with dashes between words.
+// Apostrophes don't separate words, an ampersand becomes "and".
+func Make(s string) string {
+	var b strings.Builder
+	dash := false
+	word := func(w string) {
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteString(w)
+	}
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			word(string(r))
+		case r == '\'' || r == '’' || r == '‘' || r == '`' || unicode.Is(unicode.Mn, r):
+		case r == '&':
+			dash = true
+			word("and")
+			dash = true
+		default:
+			if ascii, ok := transliterations[r]; ok {
+				word(ascii)
+			} else {
+				dash = true
+			}
+		}
+	}
+	return cut(b.String())
+}
+
+// cut shortens slug to MaxLength, at the last dash before it if there is one.
+func cut(slug string) string {
+	if len(slug) <= MaxLength {
+		return slug
+	}
+	slug = slug[:MaxLength+1]
+	if i := strings.LastIndexByte(slug, '-'); i > 0 {
+		return slug[:i]
+	}
+	return slug[:MaxLength]
+}
+
+// Movie returns the path of the movie id, with the slug of its title.
+func Movie(id int, title string) string {
+	return path("/movie", id, title)
+}
+
+// Person returns the path of the person id, with the slug of their name.
+func Person(id int, name string) string {
+	return path("/person", id, name)
+}
+
+func path(prefix string, id int, name string) string {
+	if slug := Make(name); len(slug) > 0 {
+		return fmt.Sprintf("%s/%d-%s", prefix, id, slug)
+	}
+	return fmt.Sprintf("%s/%d", prefix, id)
+}
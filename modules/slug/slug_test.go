@@ -0,0 +1,46 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Slug_Make(t *testing.T) {
+	for title, slug := range map[string]string{
+		"Army of Darkness":                 "army-of-darkness",
+		"Kill Bill Vol.1":                  "kill-bill-vol-1",
+		"  From Dusk Till Dawn!  ":         "from-dusk-till-dawn",
+		"Schindler's List":                 "schindlers-list",
+		"Léon – The Professional":          "leon-the-professional",
+		"Amélie":                           "amelie",
+		"Das Boot ist voll, Straße & Spaß": "das-boot-ist-voll-strasse-and-spass",
+		"Æon Flux":                         "aeon-flux",
+		"Łódź":                             "lodz",
+		"Сталкер":                          "stalker",
+		"Ζορμπάς":                          "zormpas",
+		"千と千尋の神隠し":                         "",
+		"Spirited Away (千と千尋の神隠し)":         "spirited-away",
+		"James Bond 007: Dr. No":           "james-bond-007-dr-no",
+		"Björk":                            "bjork",
+		"Pe\u0301rez":                      "perez", // decomposed
+	} {
+		assert.Equal(t, slug, Make(title), title)
+	}
+}
+
+func Test_Slug_MaxLength(t *testing.T) {
+	slug := Make(strings.Repeat("Night of the Living Dead ", 5))
+	assert.True(t, len(slug) <= MaxLength)
+	assert.Equal(t, "night-of-the-living-dead-night-of-the-living-dead-night-of", slug)
+
+	assert.Equal(t, strings.Repeat("a", MaxLength), Make(strings.Repeat("a", 100)))
+}
+
+func Test_Slug_Paths(t *testing.T) {
+	assert.Equal(t, "/movie/1026-army-of-darkness", Movie(1026, "Army of Darkness"))
+	assert.Equal(t, "/person/211-bruce-campbell", Person(211, "Bruce Campbell"))
+	assert.Equal(t, "/person/211", Person(211, ""))
+	assert.Equal(t, "/movie/5", Movie(5, "???"))
+}
@@ -0,0 +1,110 @@
+package suggest
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// folding maps accented latin letters to their plain counterpart.
+var folding = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'æ': "ae", 'ç': "c", 'č': "c", 'ć': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o",
+	'œ': "oe", 'ß': "ss", 'š': "s", 'ś': "s", 'ř': "r",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u",
+	'ý': "y", 'ÿ': "y", 'ž': "z", 'ź': "z", 'ż': "z", 'ł': "l", 'đ': "d",
+}
+
+// normalize lowercases s, removes accents and replaces all punctuation by single spaces,
+// i.e. "Zatôichi: The Blind Swordsman" becomes "zatoichi the blind swordsman".
+func normalize(s string) string {
+	var b bytes.Buffer
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if folded, ok := folding[r]; ok {
+			b.WriteString(folded)
+			space = false
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+			continue
+		}
+		if !space && b.Len() > 0 {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// maxTypos returns how many typos are tolerated in a query word of the given length.
+func maxTypos(length int) int {
+	switch {
+	case length < 3:
+		return 0
+	case length < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance returns the edit distance between a and b, counting insertions, deletions,
+// substitutions and transpositions of adjacent letters as one typo each.
+func distance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minimum(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minimum(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// typos returns the number of typos between the query word and the beginning of word,
+// or -1 if there are too many of them.
+func typos(query, word string) int {
+	q, w := []rune(query), []rune(word)
+	limit := maxTypos(len(q))
+	best := -1
+	// compare with prefixes around the length of the query, to match incomplete words
+	for length := len(q) - limit; length <= len(q)+limit; length++ {
+		if length < 1 || length > len(w) {
+			continue
+		}
+		if d := distance(q, w[:length]); d <= limit && (best < 0 || d < best) {
+			best = d
+		}
+	}
+	return best
+}
+
+func minimum(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
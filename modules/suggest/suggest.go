@@ -0,0 +1,293 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/slug"
+	"github.com/jamesclonk-io/stdlib/logger"
+)
+
+const (
+	Movies    = "Movies"
+	Actors    = "Actors"
+	Directors = "Directors"
+	Genres    = "Genres"
+)
+
+var (
+	log    *logrus.Logger
+	groups = []string{Movies, Actors, Directors, Genres}
+)
+
+func init() {
+	log = logger.GetLogger()
+}
+
+// Suggestion is a single search suggestion, linking to its page.
+type Suggestion struct {
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+	URL    string `json:"url"`
+	score  int
+}
+
+// Group is a ranked list of suggestions of the same kind, like Movies or Actors.
+type Group struct {
+	Name        string       `json:"name"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+type entry struct {
+	group  string
+	detail string
+	url    string
+	names  []string // the primary name first, then alternative ones
+	keys   []string // normalized names
+}
+
+// Index is an in-memory search index of movie titles, alternative titles,
+// actors, directors and genres, built from the backend.
+type Index struct {
+	source datasource.Source
+
+	mutex   sync.RWMutex
+	entries []*entry
+	updated time.Time
+
+	// alternative titles are only part of the movie details, they are fetched
+	// once per movie and kept across refreshes, until the movie is gone or changed
+	refresh   sync.Mutex
+	alttitles map[int]string
+}
+
+func New(source datasource.Source) *Index {
+	return &Index{
+		source:    source,
+		alttitles: make(map[int]string),
+	}
+}
+
+// Run refreshes the index right away and then periodically, it never returns.
+func (i *Index) Run(interval time.Duration) {
+	for {
+		if err := i.Refresh(); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Could not refresh search index")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Refresh rebuilds the index from the backend and replaces the current one.
+func (i *Index) Refresh() error {
+	i.refresh.Lock()
+	defer i.refresh.Unlock()
+
+	ctx := context.Background()
+	movies, err := i.source.Movies(ctx, api.Movies())
+	if err != nil {
+		return err
+	}
+	actors, err := i.source.Actors(ctx, api.Page{})
+	if err != nil {
+		return err
+	}
+	directors, err := i.source.Directors(ctx, api.Page{})
+	if err != nil {
+		return err
+	}
+	genres, err := i.source.Genres(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]*entry, 0, len(movies)+len(actors)+len(directors)+len(genres))
+	alttitles := make(map[int]string, len(movies))
+	for _, m := range movies {
+		alttitle, ok := i.alttitles[m.Id]
+		if ok {
+			alttitles[m.Id] = alttitle
+		} else {
+			movie, err := i.source.Movie(ctx, m.Id)
+			if err != nil {
+				// try again with the next refresh
+				log.WithFields(logrus.Fields{
+					"error": err,
+					"id":    m.Id,
+				}).Warn("Could not get alternative title")
+			} else {
+				alttitle = movie.Alttitle.String
+				alttitles[m.Id] = alttitle
+			}
+		}
+
+		names := []string{m.Title}
+		if len(alttitle) > 0 {
+			names = append(names, alttitle)
+		}
+		entries = append(entries, newEntry(Movies, slug.Movie(m.Id, m.Title), fmt.Sprintf("%d", m.Year), names...))
+	}
+	for _, p := range actors {
+		entries = append(entries, newEntry(Actors, slug.Person(p.Id, p.Name), "", p.Name))
+	}
+	for _, p := range directors {
+		entries = append(entries, newEntry(Directors, slug.Person(p.Id, p.Name), "", p.Name))
+	}
+	for _, g := range genres {
+		entries = append(entries, newEntry(Genres, fmt.Sprintf("/movies?query=genre&value=%d&sort=title&by=asc", g.Id), "", g.Name))
+	}
+
+	i.alttitles = alttitles
+
+	i.mutex.Lock()
+	i.entries = entries
+	i.updated = time.Now()
+	i.mutex.Unlock()
+	return nil
+}
+
+// Forget drops the alternative title of the movie id, it is fetched again by the next refresh.
+func (i *Index) Forget(id int) {
+	i.refresh.Lock()
+	defer i.refresh.Unlock()
+
+	delete(i.alttitles, id)
+}
+
+func newEntry(group, url, detail string, names ...string) *entry {
+	e := &entry{
+		group:  group,
+		detail: detail,
+		url:    url,
+		names:  names,
+	}
+	for _, name := range names {
+		e.keys = append(e.keys, normalize(name))
+	}
+	return e
+}
+
+// Updated returns the time of the last successful refresh.
+func (i *Index) Updated() time.Time {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.updated
+}
+
+// Suggest returns the best matches for query, at most limit per group.
+// Groups without any matches are left out.
+func (i *Index) Suggest(query string, limit int) []Group {
+	result := make([]Group, 0)
+	q := normalize(query)
+	if len(q) == 0 {
+		return result
+	}
+	words := strings.Fields(q)
+
+	matches := make(map[string][]Suggestion)
+	i.mutex.RLock()
+	for _, e := range i.entries {
+		score, key := e.match(q, words)
+		if score == 0 {
+			continue
+		}
+		s := Suggestion{Name: e.names[0], Detail: e.detail, URL: e.url, score: score}
+		if key > 0 {
+			// matched by an alternative name, show it along the primary one
+			if len(s.Detail) > 0 {
+				s.Detail = fmt.Sprintf("%s (%s)", e.names[key], s.Detail)
+			} else {
+				s.Detail = e.names[key]
+			}
+		}
+		matches[e.group] = append(matches[e.group], s)
+	}
+	i.mutex.RUnlock()
+
+	for _, group := range groups {
+		suggestions := matches[group]
+		if len(suggestions) == 0 {
+			continue
+		}
+		sort.Sort(byScore(suggestions))
+		if len(suggestions) > limit {
+			suggestions = suggestions[:limit]
+		}
+		result = append(result, Group{Name: group, Suggestions: suggestions})
+	}
+	return result
+}
+
+// match returns the score of the best matching key of e and its index, a score of 0 means no match.
+func (e *entry) match(q string, words []string) (int, int) {
+	best, index := 0, 0
+	for k, key := range e.keys {
+		score := matchKey(key, q, words)
+		if k > 0 && score > 0 {
+			// prefer the primary name on equal matches
+			score -= 5
+		}
+		if score > best {
+			best, index = score, k
+		}
+	}
+	return best, index
+}
+
+func matchKey(key, q string, words []string) int {
+	switch {
+	case key == q:
+		return 100
+	case strings.HasPrefix(key, q):
+		return 90
+	}
+
+	// every query word must match the beginning of a word in key, allowing for typos
+	total := 0
+	keyWords := strings.Fields(key)
+	for _, word := range words {
+		best := -1
+		for _, keyWord := range keyWords {
+			if t := typos(word, keyWord); t >= 0 && (best < 0 || t < best) {
+				best = t
+			}
+		}
+		if best < 0 {
+			total = -1
+			break
+		}
+		total += best
+	}
+	switch {
+	case total == 0:
+		return 80
+	case len(q) >= 3 && strings.Contains(key, q):
+		return 60
+	case total > 0 && total < 4:
+		return 50 - 10*total
+	}
+	return 0
+}
+
+type byScore []Suggestion
+
+func (s byScore) Len() int      { return len(s) }
+func (s byScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool {
+	if s[i].score != s[j].score {
+		return s[i].score > s[j].score
+	}
+	if len(s[i].Name) != len(s[j].Name) {
+		return len(s[i].Name) < len(s[j].Name)
+	}
+	return s[i].Name < s[j].Name
+}
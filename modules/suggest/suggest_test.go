@@ -0,0 +1,120 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/api"
+	"github.com/jamesclonk-io/moviedb-frontend/modules/datasource"
+	"github.com/stretchr/testify/assert"
+)
+
+var responses = map[string]string{
+	"/movies":     `[{"id":1026,"title":"Army of Darkness","year":1992},{"id":300,"title":"Zatôichi","year":2003},{"id":98,"title":"Kill Bill Vol.1","year":2003}]`,
+	"/movie/1026": `{"id":1026,"title":"Army of Darkness","alttitle":{"String":"Evil Dead 3","Valid":true},"year":1992}`,
+	"/movie/300":  `{"id":300,"title":"Zatôichi","alttitle":{"String":"","Valid":false},"year":2003}`,
+	"/movie/98":   `{"id":98,"title":"Kill Bill Vol.1","alttitle":{"String":"","Valid":false},"year":2003}`,
+	"/actors":     `[{"id":1027,"name":"Bruce Campbell"},{"id":99,"name":"Uma Thurman"}]`,
+	"/directors":  `[{"id":1028,"name":"Sam Raimi"},{"id":211,"name":"Quentin Tarantino"}]`,
+	"/genres":     `[{"id":3,"name":"Comedy"},{"id":9,"name":"Horror"}]`,
+}
+
+func newTestIndex(t *testing.T) *Index {
+	return newIndex(t, responses)
+}
+
+func newIndex(t *testing.T, responses map[string]string) *Index {
+	index := New(datasource.NewBackend(api.GetterFunc(func(ctx context.Context, url string) (string, error) {
+		response, ok := responses[url[len("http://backend"):]]
+		if !ok {
+			return "", fmt.Errorf("not found: %s", url)
+		}
+		return response, nil
+	}), nil, "http://backend"))
+	assert.NoError(t, index.Refresh())
+	return index
+}
+
+func Test_Suggest_Normalize(t *testing.T) {
+	assert.Equal(t, "zatoichi the blind swordsman", normalize("Zatôichi: The Blind  Swordsman"))
+	assert.Equal(t, "o brother where art thou", normalize("O Brother, Where Art Thou?"))
+	assert.Equal(t, "", normalize(" - "))
+}
+
+func Test_Suggest_Typos(t *testing.T) {
+	assert.Equal(t, 0, typos("dark", "darkness"))
+	assert.Equal(t, 1, typos("drak", "darkness"))
+	assert.Equal(t, 1, typos("tarantnio", "tarantino"))
+	assert.Equal(t, 2, typos("tarnatnio", "tarantino"))
+	assert.Equal(t, -1, typos("ab", "ba"))
+	assert.Equal(t, -1, typos("horror", "comedy"))
+}
+
+func Test_Suggest_Suggest(t *testing.T) {
+	index := newTestIndex(t)
+
+	groups := index.Suggest("zatoichi", 5)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, Movies, groups[0].Name)
+	assert.Equal(t, []Suggestion{{Name: "Zatôichi", Detail: "2003", URL: "/movie/300-zatoichi", score: 100}}, groups[0].Suggestions)
+
+	groups = index.Suggest("evil dead", 5)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "Army of Darkness", groups[0].Suggestions[0].Name)
+	assert.Equal(t, "Evil Dead 3 (1992)", groups[0].Suggestions[0].Detail)
+
+	groups = index.Suggest("Tarantnio", 5)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, Directors, groups[0].Name)
+	assert.Equal(t, "/person/211-quentin-tarantino", groups[0].Suggestions[0].URL)
+
+	groups = index.Suggest("HOR", 5)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, Genres, groups[0].Name)
+	assert.Equal(t, "/movies?query=genre&value=9&sort=title&by=asc", groups[0].Suggestions[0].URL)
+
+	assert.Equal(t, 0, len(index.Suggest("", 5)))
+	assert.Equal(t, 0, len(index.Suggest("xyzzy", 5)))
+}
+
+func Test_Suggest_Alttitles(t *testing.T) {
+	changed := make(map[string]string)
+	for url, response := range responses {
+		changed[url] = response
+	}
+	index := newIndex(t, changed)
+
+	changed["/movies"] = `[{"id":1026,"title":"Army of Darkness","year":1992},{"id":300,"title":"Zatôichi","year":2003}]`
+	changed["/movie/1026"] = `{"id":1026,"title":"Army of Darkness","alttitle":{"String":"Captain Supermarket","Valid":true},"year":1992}`
+	assert.NoError(t, index.Refresh())
+	assert.Equal(t, map[int]string{1026: "Evil Dead 3", 300: ""}, index.alttitles)
+	assert.Equal(t, 0, len(index.Suggest("captain supermarket", 5)))
+
+	index.Forget(1026)
+	assert.NoError(t, index.Refresh())
+	groups := index.Suggest("captain supermarket", 5)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "Army of Darkness", groups[0].Suggestions[0].Name)
+	assert.Equal(t, 0, len(index.Suggest("evil dead", 5)))
+}
+
+func Test_Suggest_Ranking(t *testing.T) {
+	index := newTestIndex(t)
+
+	groups := index.Suggest("a", 5)
+	assert.Equal(t, Movies, groups[0].Name)
+	assert.Equal(t, "Army of Darkness", groups[0].Suggestions[0].Name)
+
+	groups = index.Suggest("c", 1)
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, Actors, groups[0].Name)
+	assert.Equal(t, "Bruce Campbell", groups[0].Suggestions[0].Name)
+	assert.Equal(t, Genres, groups[1].Name)
+	assert.Equal(t, "Comedy", groups[1].Suggestions[0].Name)
+
+	groups = index.Suggest("b", 1)
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, 1, len(groups[0].Suggestions))
+	assert.Equal(t, "Kill Bill Vol.1", groups[0].Suggestions[0].Name)
+}
@@ -0,0 +1,131 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/breaker"
+)
+
+// Kind classifies why a backend request failed.
+type Kind int
+
+const (
+	// BadGateway is any failure of the backend not classified otherwise.
+	BadGateway Kind = iota
+	// NotFound means the backend has no such resource.
+	NotFound
+	// Unauthorized means the backend rejected the credentials of the frontend.
+	Unauthorized
+	// Timeout means the backend did not respond in time.
+	Timeout
+	// Unavailable means the circuit breaker did not even try the backend.
+	Unavailable
+	// Decode means the backend response could not be decoded.
+	Decode
+)
+
+var kinds = map[Kind]struct {
+	name    string
+	status  int
+	message string
+}{
+	BadGateway:   {"bad gateway", http.StatusBadGateway, "The movie database failed to answer."},
+	NotFound:     {"not found", http.StatusNotFound, "This is not the page you are looking for.."},
+	Unauthorized: {"unauthorized", http.StatusBadGateway, "The movie database refused to answer."},
+	Timeout:      {"timeout", http.StatusGatewayTimeout, "The movie database took too long to answer."},
+	Unavailable:  {"unavailable", http.StatusServiceUnavailable, "The movie database is currently unavailable."},
+	Decode:       {"decode failure", http.StatusBadGateway, "The movie database sent an invalid answer."},
+}
+
+func (k Kind) String() string {
+	return kinds[k].name
+}
+
+// Error is a classified backend error. Its message is safe to show to users,
+// it never contains the backend URL or response body.
+type Error struct {
+	Kind   Kind
+	Status int   // of the backend response, 0 if there was none
+	Err    error // the underlying error, for logging only
+}
+
+func (e *Error) Error() string {
+	return kinds[e.Kind].message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode is the status the frontend should respond with.
+func (e *Error) StatusCode() int {
+	return kinds[e.Kind].status
+}
+
+// Classify returns err as *Error, classifying it if it is not one yet.
+func Classify(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return &Error{Kind: Decode, Err: err}
+	case errors.Is(err, breaker.ErrOpen):
+		return &Error{Kind: Unavailable, Err: err}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Kind: Timeout, Err: err}
+	}
+	return &Error{Kind: BadGateway, Err: err}
+}
+
+// Is reports whether err is a backend error of kind.
+func Is(err error, kind Kind) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Kind == kind
+}
+
+// Transport is an http.RoundTripper turning error responses of the backend into *Error,
+// discarding their body.
+type Transport struct {
+	Next http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < http.StatusBadRequest {
+		return res, nil
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	e := &Error{Kind: BadGateway, Status: res.StatusCode, Err: fmt.Errorf("backend responded %s", res.Status)}
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		e.Kind = NotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		e.Kind = Unauthorized
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		e.Kind = Timeout
+	}
+	return nil, e
+}
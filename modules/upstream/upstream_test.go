@@ -0,0 +1,90 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jamesclonk-io/moviedb-frontend/modules/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func get(status int) (string, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, "secret backend details")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	res, err := client.Get(server.URL)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	return string(body), err
+}
+
+func Test_Upstream_Transport(t *testing.T) {
+	body, err := get(http.StatusOK)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret backend details", body)
+
+	for status, kind := range map[int]Kind{
+		http.StatusNotFound:            NotFound,
+		http.StatusUnauthorized:        Unauthorized,
+		http.StatusForbidden:           Unauthorized,
+		http.StatusGatewayTimeout:      Timeout,
+		http.StatusInternalServerError: BadGateway,
+		http.StatusBadRequest:          BadGateway,
+	} {
+		_, err := get(status)
+		assert.True(t, Is(err, kind), http.StatusText(status))
+		assert.Equal(t, status, Classify(err).Status)
+		assert.NotContains(t, err.Error(), "secret backend details")
+	}
+}
+
+func Test_Upstream_Classify(t *testing.T) {
+	var v []int
+	syntaxErr := json.Unmarshal([]byte(`{"broken"`), &v)
+	typeErr := json.Unmarshal([]byte(`"text"`), &v)
+
+	for _, test := range []struct {
+		err    error
+		kind   Kind
+		status int
+	}{
+		{syntaxErr, Decode, http.StatusBadGateway},
+		{typeErr, Decode, http.StatusBadGateway},
+		{context.DeadlineExceeded, Timeout, http.StatusGatewayTimeout},
+		{fmt.Errorf("get: %w", breaker.ErrOpen), Unavailable, http.StatusServiceUnavailable},
+		{errors.New("secret backend details"), BadGateway, http.StatusBadGateway},
+		{&Error{Kind: NotFound}, NotFound, http.StatusNotFound},
+	} {
+		e := Classify(test.err)
+		assert.Equal(t, test.kind, e.Kind, test.err.Error())
+		assert.Equal(t, test.status, e.StatusCode())
+		assert.NotContains(t, e.Error(), "secret backend details")
+	}
+
+	assert.True(t, errors.Is(Classify(fmt.Errorf("get: %w", breaker.ErrOpen)), breaker.ErrOpen))
+}
+
+func Test_Upstream_ClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}, Timeout: 10 * time.Millisecond}
+	_, err := client.Get(server.URL)
+	assert.Equal(t, Timeout, Classify(err).Kind)
+}